@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/bundle"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/gitstore"
+)
+
+// runCommand 执行命令行子命令，不是子命令时返回 false
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "export":
+		exportCommand(args[1:])
+	case "import":
+		importCommand(args[1:])
+	default:
+		return false
+	}
+	return true
+}
+
+// exportCommand 导出脚本包到文件
+func exportCommand(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "", "Output file, - for stdout (default: hook-panel-<time>.zip)")
+	ids := fs.String("ids", "", "Comma separated script IDs to export (default: all scripts)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s export [options]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	openStorage()
+
+	var selected []string
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			selected = append(selected, id)
+		}
+	}
+
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("hook-panel-%s.zip", time.Now().Format("20060102-150405"))
+	}
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			log.Fatal("Failed to create output file:", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := bundle.Export(selected, w); err != nil {
+		log.Fatal("Failed to export scripts:", err)
+	}
+	if path != "-" {
+		log.Printf("📦 Scripts exported to %s", path)
+	}
+}
+
+// importCommand 从文件导入脚本包，运行中的服务需要重启后才会加载新的定时和文件监听配置
+func importCommand(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	strategy := fs.String("strategy", models.ImportSkip, "Conflict strategy: skip, overwrite or rename")
+	remapIDs := fs.Bool("remap-ids", false, "Generate new IDs instead of keeping the IDs in the bundle")
+	dryRun := fs.Bool("dry-run", false, "Only print what would be imported")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s import [options] <bundle.zip | ->\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	switch *strategy {
+	case models.ImportSkip, models.ImportOverwrite, models.ImportRename:
+	default:
+		log.Fatalf("Unknown conflict strategy %q", *strategy)
+	}
+
+	var data []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		data, err = io.ReadAll(io.LimitReader(os.Stdin, bundle.MaxSize+1))
+	} else {
+		data, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		log.Fatal("Failed to read bundle:", err)
+	}
+	if len(data) > bundle.MaxSize {
+		log.Fatalf("Bundle exceeds %d MB", bundle.MaxSize>>20)
+	}
+	b, err := bundle.Read(data)
+	if err != nil {
+		log.Fatal("Invalid bundle:", err)
+	}
+
+	openStorage()
+
+	result, err := bundle.Import(b, models.BundleImportRequest{
+		Strategy: *strategy,
+		RemapIDs: *remapIDs,
+		DryRun:   *dryRun,
+	}, "cli")
+	if err != nil {
+		log.Fatal("Failed to import bundle:", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tNAME\tID\tDETAIL")
+	for _, item := range result.Items {
+		detail := item.Error
+		if detail == "" && item.Conflict != "" {
+			detail = "conflicts with " + item.Conflict
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", item.Action, item.Name, item.ID, detail)
+	}
+	tw.Flush()
+
+	prefix := "Imported"
+	if result.DryRun {
+		prefix = "Dry run"
+	}
+	fmt.Printf("\n%s: %d created, %d overwritten, %d skipped, %d failed\n",
+		prefix, result.Created, result.Overwritten, result.Skipped, result.Failed)
+	if !result.DryRun && result.Created+result.Overwritten > 0 {
+		fmt.Println("Restart the running service to apply imported schedules and file watches.")
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// openStorage 打开数据库和脚本存储
+func openStorage() {
+	if err := database.InitDatabase(""); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	if err := gitstore.Init(); err != nil {
+		log.Fatal("Failed to initialize git storage:", err)
+	}
+}
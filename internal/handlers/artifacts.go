@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/artifact"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetRunArtifacts 获取运行产物列表
+func GetRunArtifacts(c *gin.Context) {
+	run, ok := findRun(c)
+	if !ok {
+		return
+	}
+
+	artifacts, err := artifact.List(run.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.artifact.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   artifacts,
+		"run_id": run.ID,
+	})
+}
+
+// DownloadRunArtifact 下载单个产物文件
+func DownloadRunArtifact(c *gin.Context) {
+	run, ok := findRun(c)
+	if !ok {
+		return
+	}
+
+	filePath, err := artifact.Path(run.ID, c.Query("path"))
+	if err != nil {
+		if err == artifact.ErrInvalidPath {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.request.invalid_params", "path"),
+			})
+			return
+		}
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.artifact.not_found"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.artifact.get_failed"),
+		})
+		return
+	}
+
+	c.FileAttachment(filePath, path.Base(c.Query("path")))
+}
+
+// DownloadRunArtifactsZip 将运行的全部产物打包下载
+func DownloadRunArtifactsZip(c *gin.Context) {
+	run, ok := findRun(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="artifacts-%s.zip"`, run.ID))
+	c.Status(http.StatusOK)
+
+	if err := artifact.WriteZip(run.ID, c.Writer); err != nil {
+		// 响应头已发送，只能中断连接
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// findRun 根据路由参数查找运行记录，不存在时直接写入错误响应
+func findRun(c *gin.Context) (*models.ScriptRun, bool) {
+	runID := c.Param("runId")
+	if runID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", "Run ID"),
+		})
+		return nil, false
+	}
+
+	db := database.GetDB()
+	var run models.ScriptRun
+	if err := db.First(&run, "id = ?", runID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.run.not_found"),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return nil, false
+	}
+	if !requireScriptIDRole(c, run.ScriptID, models.RoleViewer) {
+		return nil, false
+	}
+
+	return &run, true
+}
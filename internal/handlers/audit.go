@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditEvents 获取审计日志，支持按操作者、操作、对象、IP 和时间筛选
+func GetAuditEvents(c *gin.Context) {
+	var q models.AuditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	// 分页参数
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+
+	var total int64
+	if err := audit.Query(q).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.audit.get_failed"),
+		})
+		return
+	}
+
+	var events []models.AuditEvent
+	offset := (q.Page - 1) * q.PageSize
+	if err := audit.Query(q).Order("created_at DESC").Offset(offset).Limit(q.PageSize).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.audit.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     events,
+		"page":     q.Page,
+		"pageSize": q.PageSize,
+		"total":    total,
+	})
+}
+
+// ExportAuditEvents 以 JSON Lines 格式导出审计日志，筛选条件与 GetAuditEvents 相同
+func ExportAuditEvents(c *gin.Context) {
+	var q models.AuditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="hook-panel-audit-%s.jsonl"`, time.Now().Format("20060102-150405")))
+	c.Status(http.StatusOK)
+
+	if err := audit.Export(q, c.Writer); err != nil {
+		// 响应头已发送，只能中断连接
+		c.Error(err)
+		c.Abort()
+	}
+}
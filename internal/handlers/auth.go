@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/lockout"
+	"hook-panel/internal/pkg/sysconfig"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Login 使用用户名和密码登录，返回会话令牌
+func Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	audit.SetActor(c, req.Username)
+	if !lockout.Guard(c) {
+		return
+	}
+	user, err := auth.Authenticate(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			lockout.Fail(c, lockout.ScopeLogin)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": i18n.T(c, "error.auth.invalid_credentials"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+
+	lockout.Reset(c.ClientIP(), lockout.ScopeLogin)
+
+	// 已启用两步验证时还需要验证码或恢复码
+	if user.TOTPEnabled {
+		if req.Code == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":         i18n.T(c, "error.auth.totp_required"),
+				"totp_required": true,
+			})
+			return
+		}
+		if err := auth.VerifySecondFactor(user, req.Code); err != nil {
+			if errors.Is(err, auth.ErrInvalidTOTP) {
+				lockout.Fail(c, lockout.ScopeTOTP)
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":         i18n.T(c, "error.auth.invalid_totp"),
+					"totp_required": true,
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.database.query_failed"),
+			})
+			return
+		}
+		lockout.Reset(c.ClientIP(), lockout.ScopeTOTP)
+	}
+
+	token, session, err := auth.CreateSession(user, models.SessionPassword, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.auth.login_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		Token:                  token,
+		ExpiresAt:              session.ExpiresAt,
+		User:                   *user,
+		TOTPEnrollmentRequired: auth.EnrollmentRequired(user, session),
+	})
+}
+
+// Logout 注销当前会话
+func Logout(c *gin.Context) {
+	identity := auth.CurrentIdentity(c)
+	if identity == nil || identity.Session == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.auth.no_session"),
+		})
+		return
+	}
+
+	if err := auth.DeleteSession(identity.Session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.auth.logged_out"),
+	})
+}
+
+// GetCurrentUser 获取当前登录的用户，使用访问密钥时 user 为空
+func GetCurrentUser(c *gin.Context) {
+	identity := auth.CurrentIdentity(c)
+	response := gin.H{
+		"name": identity.Name(),
+		"key":  identity.IsKey(),
+		"role": identity.Role(),
+		"user": identity.User,
+	}
+	if identity.Session != nil {
+		response["expires_at"] = identity.Session.ExpiresAt
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ChangePassword 修改当前用户的密码，并注销该用户的其他会话
+func ChangePassword(c *gin.Context) {
+	var req models.PasswordChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	identity := auth.CurrentIdentity(c)
+	if identity == nil || identity.User == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.auth.no_session"),
+		})
+		return
+	}
+	// 当前密码与登录密码一样计入失败次数，避免借已登录的会话绕过锁定猜测密码
+	if !lockout.Guard(c) {
+		return
+	}
+	if !auth.CheckPassword(identity.User, req.CurrentPassword) {
+		lockout.Fail(c, lockout.ScopeLogin)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.auth.wrong_password"),
+		})
+		return
+	}
+	lockout.Reset(c.ClientIP(), lockout.ScopeLogin)
+
+	hash, ok := hashPassword(c, req.NewPassword)
+	if !ok {
+		return
+	}
+	if err := database.GetDB().Model(identity.User).Update("password_hash", hash).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.update_failed"),
+		})
+		return
+	}
+	auth.DeleteUserSessions(identity.User.ID, identity.Session.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.auth.password_changed"),
+	})
+}
+
+// RotateKey 轮换访问密钥。旧密钥和用旧密钥签名的 webhook 地址在宽限期内仍然有效，
+// 响应中包含新密钥（只显示这一次）和需要更新的全部 webhook 地址
+func RotateKey(c *gin.Context) {
+	var req models.KeyRotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+	graceHours := sysconfig.GetInt("auth.key_rotation_grace_hours", 24)
+	if req.GraceHours != nil {
+		graceHours = *req.GraceHours
+	}
+	if graceHours < 0 {
+		graceHours = 0
+	}
+
+	// 先读取全部 webhook，避免密钥已轮换却无法给出需要更新的地址
+	db := database.GetDB()
+	var scripts []models.Script
+	var workflows []models.Workflow
+	if err := db.Order("created_at").Find(&scripts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+	if err := db.Order("created_at").Find(&workflows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+	domain, err := webhookDomain(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.config.get_failed"),
+		})
+		return
+	}
+
+	expiresAt, err := auth.RotateSecretKey(time.Duration(graceHours) * time.Hour)
+	if err != nil {
+		if errors.Is(err, auth.ErrExternalKey) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": i18n.T(c, "error.auth.external_key"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.auth.rotate_key_failed"),
+		})
+		return
+	}
+
+	webhooks := make([]models.WebhookURLUpdate, 0, len(scripts)+len(workflows))
+	for _, script := range scripts {
+		webhooks = append(webhooks, models.WebhookURLUpdate{
+			Type:       "script",
+			ID:         script.ID,
+			Name:       script.Name,
+			Enabled:    script.Enabled,
+			WebhookURL: fmt.Sprintf("%s/h/%s?signature=%s", domain, script.ID, generateWebhookSignature(script.ID)),
+		})
+	}
+	for _, wf := range workflows {
+		webhooks = append(webhooks, models.WebhookURLUpdate{
+			Type:       "workflow",
+			ID:         wf.ID,
+			Name:       wf.Name,
+			Enabled:    wf.Enabled,
+			WebhookURL: fmt.Sprintf("%s/hw/%s?signature=%s", domain, wf.ID, generateWebhookSignature(wf.ID)),
+		})
+	}
+
+	message := i18n.T(c, "success.auth.key_rotated_immediately")
+	if graceHours > 0 {
+		message = i18n.T(c, "success.auth.key_rotated", expiresAt.Format(time.RFC3339))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data": models.KeyRotationResponse{
+			Key:                  auth.GetSecretKey(),
+			PreviousKeyExpiresAt: expiresAt,
+			Webhooks:             webhooks,
+		},
+	})
+}
+
+// hashPassword 生成密码哈希，密码不符合要求时直接写入错误响应
+func hashPassword(c *gin.Context, password string) (string, bool) {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		if errors.Is(err, auth.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.auth.weak_password", auth.MinPasswordLength),
+			})
+			return "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.update_failed"),
+		})
+		return "", false
+	}
+	return hash, true
+}
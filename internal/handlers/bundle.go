@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/bundle"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/scheduler"
+	"hook-panel/internal/pkg/watcher"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportBundle 导出脚本包，可通过 ids 参数（逗号分隔）指定导出的脚本。
+// 限定脚本的 API 令牌只能导出授权的脚本
+func ExportBundle(c *gin.Context) {
+	var ids []string
+	for _, id := range strings.Split(c.Query("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if scope := auth.CurrentIdentity(c).ScriptScope(); scope != nil {
+		if len(ids) == 0 {
+			ids = scope
+		}
+		for _, id := range ids {
+			if !requireScriptIDRole(c, id, models.RoleViewer) {
+				return
+			}
+		}
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="hook-panel-%s.zip"`, time.Now().Format("20060102-150405")))
+	c.Status(http.StatusOK)
+
+	if err := bundle.Export(ids, c.Writer); err != nil {
+		// 响应头已发送，只能中断连接
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// ImportBundle 导入脚本包，支持 multipart 的 file 字段或直接上传压缩包
+func ImportBundle(c *gin.Context) {
+	var req models.BundleImportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	data, err := readBundleUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.bundle.invalid", err.Error()),
+		})
+		return
+	}
+	b, err := bundle.Read(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.bundle.invalid", err.Error()),
+		})
+		return
+	}
+
+	result, err := bundle.Import(b, req, requestAuthor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.bundle.import_failed"),
+		})
+		return
+	}
+
+	for _, id := range result.Imported() {
+		scheduler.Reload(id)
+		watcher.Reload(id)
+	}
+	if !req.DryRun {
+		created, overwritten := result.Changed()
+		audit.SetChange(c, "bundle", nil, gin.H{"created": created, "overwritten": overwritten})
+	}
+
+	message := i18n.T(c, "success.bundle.imported", result.Created, result.Overwritten, result.Skipped, result.Failed)
+	if req.DryRun {
+		message = i18n.T(c, "success.bundle.dry_run", result.Created, result.Overwritten, result.Skipped, result.Failed)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    result,
+	})
+}
+
+// readBundleUpload 读取上传的脚本包
+func readBundleUpload(c *gin.Context) ([]byte, error) {
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		f, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		body = f
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, bundle.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > bundle.MaxSize {
+		return nil, fmt.Errorf("bundle exceeds %d MB", bundle.MaxSize>>20)
+	}
+	return data, nil
+}
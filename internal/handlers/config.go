@@ -6,8 +6,10 @@ import (
 
 	"hook-panel/internal/middleware"
 	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
 	"hook-panel/internal/pkg/database"
 	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/sysconfig"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -58,7 +60,7 @@ func GetSystemConfigs(c *gin.Context) {
 
 		// 如果是加密字段，不返回实际值
 		if config.Encrypted && config.Value != "" {
-			response.Value = "******"
+			response.Value = sysconfig.MaskedValue
 		}
 
 		categoryMap[config.Category] = append(categoryMap[config.Category], response)
@@ -107,7 +109,9 @@ func UpdateSystemConfigs(c *gin.Context) {
 		}
 	}()
 
-	// 更新每个配置项
+	// 更新每个配置项，记录有变化的值用于审计日志
+	before := make(map[string]string)
+	after := make(map[string]string)
 	for _, configItem := range req.Configs {
 		var config models.SystemConfig
 		if err := tx.Where("key = ?", configItem.Key).First(&config).Error; err != nil {
@@ -125,6 +129,11 @@ func UpdateSystemConfigs(c *gin.Context) {
 			return
 		}
 
+		// 加密字段提交掩码表示保持原值不变
+		if config.Encrypted && configItem.Value == sysconfig.MaskedValue {
+			continue
+		}
+
 		// 验证必填项
 		if config.Required && configItem.Value == "" {
 			tx.Rollback()
@@ -139,8 +148,32 @@ func UpdateSystemConfigs(c *gin.Context) {
 			return
 		}
 
+		value := configItem.Value
+		if config.Encrypted {
+			// 与解密后的原值比较，重新提交相同的值不记为修改；原值已加密时无需重写
+			previous, err := sysconfig.Decrypt(config.Key, config.Value)
+			unchanged := err == nil && previous == value
+			if unchanged && sysconfig.IsEncrypted(config.Value) {
+				continue
+			}
+			if !unchanged {
+				before[config.Key], after[config.Key] = sysconfig.MaskedValue, "(changed)"
+			}
+			encrypted, err := sysconfig.Encrypt(config.Key, value)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": i18n.T(c, "error.config.update_failed"),
+				})
+				return
+			}
+			value = encrypted
+		} else if config.Value != value {
+			before[config.Key], after[config.Key] = config.Value, value
+		}
+
 		// 更新配置值
-		if err := tx.Model(&config).Update("value", configItem.Value).Error; err != nil {
+		if err := tx.Model(&config).Update("value", value).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": i18n.T(c, "error.config.update_failed"),
@@ -157,6 +190,8 @@ func UpdateSystemConfigs(c *gin.Context) {
 		return
 	}
 
+	audit.SetChange(c, "config", before, after)
+
 	// 检查是否更新了语言配置，如果是则刷新缓存
 	for _, configItem := range req.Configs {
 		if configItem.Key == "system.language" {
@@ -171,19 +206,9 @@ func UpdateSystemConfigs(c *gin.Context) {
 	})
 }
 
-// GetConfigValue 获取单个配置值（内部使用）
+// GetConfigValue 获取单个配置值（内部使用），加密的配置返回解密后的值
 func GetConfigValue(key string) (string, error) {
-	db := database.GetDB()
-
-	var config models.SystemConfig
-	if err := db.Where("key = ?", key).First(&config).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return "", nil // 配置不存在返回空字符串
-		}
-		return "", err
-	}
-
-	return config.Value, nil
+	return sysconfig.Get(key)
 }
 
 // SetConfigValue 设置单个配置值（内部使用）
@@ -198,6 +223,13 @@ func SetConfigValue(key, value string) error {
 		return err
 	}
 
+	if config.Encrypted {
+		encrypted, err := sysconfig.Encrypt(key, value)
+		if err != nil {
+			return err
+		}
+		value = encrypted
+	}
 	return db.Model(&config).Update("value", value).Error
 }
 
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/lockout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLockouts 获取有认证失败记录的来源 IP，包括正在锁定中的
+func GetLockouts(c *gin.Context) {
+	lockouts := lockout.List()
+	c.JSON(http.StatusOK, gin.H{
+		"data":      lockouts,
+		"total":     len(lockouts),
+		"threshold": lockout.Threshold(),
+	})
+}
+
+// ClearLockout 清除单个 IP 的失败记录并解除锁定
+func ClearLockout(c *gin.Context) {
+	ip := c.Param("ip")
+	if !lockout.Clear(ip) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": i18n.T(c, "error.lockout.not_found", ip),
+		})
+		return
+	}
+
+	audit.SetChange(c, ip, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.lockout.cleared", ip),
+	})
+}
+
+// ClearLockouts 清除全部失败记录并解除锁定
+func ClearLockouts(c *gin.Context) {
+	count := lockout.ClearAll()
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.lockout.cleared_all", count),
+		"count":   count,
+	})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/oidc"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOIDCStatus 返回是否启用单点登录，供登录页决定是否显示入口
+func GetOIDCStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": oidc.Enabled(),
+	})
+}
+
+// OIDCLogin 跳转到身份提供方登录，redirect 为登录成功后返回的站内路径
+func OIDCLogin(c *gin.Context) {
+	redirect := c.DefaultQuery("redirect", "/")
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.Contains(redirect, "\\") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.oidc.invalid_redirect"),
+		})
+		return
+	}
+
+	cfg, ok := loadOIDCConfig(c)
+	if !ok {
+		return
+	}
+	target, state, err := oidc.AuthURL(cfg, redirect)
+	if err != nil {
+		if errors.Is(err, oidc.ErrTooManyLogins) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": i18n.T(c, "error.oidc.too_many_logins"),
+			})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": i18n.T(c, "error.oidc.login_failed", err.Error()),
+		})
+		return
+	}
+	setStateCookie(c, cfg, state, int(oidc.StateTTL/time.Second))
+	c.Redirect(http.StatusFound, target)
+}
+
+// OIDCCallback 处理身份提供方的授权回调：换取并校验 ID Token，开通或同步账号后创建会话，
+// 再跳转回发起登录时的站内路径，会话令牌放在 URL 片段中（不会发送到服务器日志）
+func OIDCCallback(c *gin.Context) {
+	// 回调是 GET 请求，审计中间件不会记录，这里单独记录登录结果
+	defer audit.Record(c)
+
+	cfg, ok := loadOIDCConfig(c)
+	if !ok {
+		return
+	}
+	if idpError := c.Query("error"); idpError != "" {
+		message := idpError
+		if description := c.Query("error_description"); description != "" {
+			message += ": " + description
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": i18n.T(c, "error.oidc.login_failed", message),
+		})
+		return
+	}
+
+	cookieState, _ := c.Cookie(oidc.StateCookie)
+	setStateCookie(c, cfg, "", -1)
+	identity, err := oidc.Exchange(cfg, c.Query("state"), cookieState, c.Query("code"))
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidState) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.oidc.invalid_state"),
+			})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": i18n.T(c, "error.oidc.login_failed", err.Error()),
+		})
+		return
+	}
+	audit.SetActor(c, identity.Username)
+
+	user, before, err := oidc.ProvisionUser(cfg, identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrUsernameTaken):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": i18n.T(c, "error.oidc.username_taken", identity.Username),
+			})
+		case errors.Is(err, oidc.ErrNotProvisioned):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": i18n.T(c, "error.oidc.not_provisioned", identity.Username),
+			})
+		case errors.Is(err, oidc.ErrNoRole):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": i18n.T(c, "error.oidc.no_role", identity.Username),
+			})
+		case errors.Is(err, oidc.ErrUserDisabled):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": i18n.T(c, "error.oidc.user_disabled"),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.auth.login_failed"),
+			})
+		}
+		return
+	}
+
+	token, session, err := auth.CreateSession(user, models.SessionOIDC, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.auth.login_failed"),
+		})
+		return
+	}
+	// 首次登录开通的账号、关联的账号和按分组同步的角色变化都记录在登录事件中
+	audit.SetChange(c, user.ID, before, user)
+
+	fragment := url.Values{}
+	fragment.Set("token", token)
+	fragment.Set("expires_at", session.ExpiresAt.Format(time.RFC3339))
+	c.Redirect(http.StatusFound, identity.Redirect+"#"+fragment.Encode())
+}
+
+// setStateCookie 写入或清除（maxAge 为 -1）保存 state 的 Cookie。
+// 身份提供方跳转回来属于跨站导航，SameSite 只能使用 Lax
+func setStateCookie(c *gin.Context, cfg *oidc.Config, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	secure := c.Request.TLS != nil || strings.HasPrefix(cfg.RedirectURL, "https://")
+	c.SetCookie(oidc.StateCookie, state, maxAge, oidc.CallbackPath, "", secure, true)
+}
+
+// loadOIDCConfig 读取单点登录配置，未启用或配置错误时直接写入错误响应
+func loadOIDCConfig(c *gin.Context) (*oidc.Config, bool) {
+	cfg, err := oidc.LoadConfig()
+	if err != nil {
+		if errors.Is(err, oidc.ErrDisabled) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.oidc.disabled"),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.oidc.login_failed", err.Error()),
+		})
+		return nil, false
+	}
+	return cfg, true
+}
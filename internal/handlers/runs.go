@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/artifact"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetScriptRuns 获取脚本的运行记录
+func GetScriptRuns(c *gin.Context) {
+	scriptID := c.Param("id")
+	if scriptID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", "Script ID"),
+		})
+		return
+	}
+
+	var req models.ScriptRunListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100 // 限制最大页面大小
+	}
+
+	db := database.GetDB()
+	query := db.Model(&models.ScriptRun{}).Where("script_id = ?", scriptID)
+
+	// 筛选条件
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Trigger != "" {
+		query = query.Where("trigger = ?", req.Trigger)
+	}
+
+	// 获取总数
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.run.get_failed"),
+		})
+		return
+	}
+
+	// 分页查询
+	var runs []models.ScriptRun
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("started_at DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.run.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ScriptRunListResponse{
+		Data:     runs,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
+
+// GetRun 获取单次运行记录（包含全部尝试）
+func GetRun(c *gin.Context) {
+	runID := c.Param("runId")
+	if runID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", "Run ID"),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var run models.ScriptRun
+	if err := db.Preload("AttemptList", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("attempt ASC")
+	}).First(&run, "id = ?", runID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.run.not_found"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+	if !requireScriptIDRole(c, run.ScriptID, models.RoleViewer) {
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// deleteScriptRuns 删除脚本的全部运行记录（内部函数）
+func deleteScriptRuns(scriptID string) {
+	db := database.GetDB()
+
+	// 删除运行产物
+	var runIDs []string
+	db.Model(&models.ScriptRun{}).Where("script_id = ?", scriptID).Pluck("id", &runIDs)
+	for _, runID := range runIDs {
+		artifact.Delete(runID)
+	}
+
+	db.Where("run_id IN (?)", db.Model(&models.ScriptRun{}).Select("id").Where("script_id = ?", scriptID)).
+		Delete(&models.ScriptRunAttempt{})
+	db.Where("script_id = ?", scriptID).Delete(&models.ScriptRun{})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/params"
+	"hook-panel/internal/pkg/scheduler"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateScheduledRun 创建一次性的延时/定点运行
+func CreateScheduledRun(c *gin.Context) {
+	scriptID := c.Param("id")
+	if scriptID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", "Script ID"),
+		})
+		return
+	}
+
+	var req models.ScheduledRunCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	// 计算执行时间，run_at 与 delay 二选一
+	now := time.Now()
+	var runAt time.Time
+	switch {
+	case req.RunAt != nil && req.Delay != 0:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.scheduled_run.invalid_time", "run_at and delay are mutually exclusive"),
+		})
+		return
+	case req.RunAt != nil:
+		runAt = *req.RunAt
+	case req.Delay > 0:
+		runAt = now.Add(time.Duration(req.Delay) * time.Second)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.scheduled_run.invalid_time", "run_at or a positive delay is required"),
+		})
+		return
+	}
+	if !runAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.scheduled_run.invalid_time", "run_at must be in the future"),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var script models.Script
+	if err := db.First(&script, "id = ?", scriptID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.script.not_found"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+
+	// 提前校验参数，执行时会按脚本当时的参数定义再次校验
+	if _, err := params.Resolve(script.Parameters, req.Params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.script.invalid_parameters", err.Error()),
+		})
+		return
+	}
+
+	run := models.ScheduledRun{
+		ScriptID: scriptID,
+		RunAt:    runAt,
+		Params:   req.Params,
+		Stdin:    req.Stdin,
+		Status:   models.ScheduledRunPending,
+	}
+	if err := db.Create(&run).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.scheduled_run.create_failed"),
+		})
+		return
+	}
+
+	scheduler.Enqueue(run)
+	audit.SetChange(c, run.ID, nil, run)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "success.scheduled_run.created"),
+		"data":    run,
+	})
+}
+
+// GetScheduledRuns 获取计划运行列表，带脚本 ID 时只返回该脚本的
+func GetScheduledRuns(c *gin.Context) {
+	var req models.ScheduledRunListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100 // 限制最大页面大小
+	}
+
+	db := database.GetDB()
+	query := scopeScripts(c, db.Model(&models.ScheduledRun{}), "script_id")
+
+	// 筛选条件
+	if scriptID := c.Param("id"); scriptID != "" {
+		query = query.Where("script_id = ?", scriptID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	// 获取总数
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.scheduled_run.get_failed"),
+		})
+		return
+	}
+
+	// 分页查询，最近要执行的排在前面
+	var runs []models.ScheduledRun
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("run_at ASC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.scheduled_run.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ScheduledRunListResponse{
+		Data:     runs,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
+
+// CancelScheduledRun 取消等待中的计划运行
+func CancelScheduledRun(c *gin.Context) {
+	id := c.Param("scheduleId")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", "Schedule ID"),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var run models.ScheduledRun
+	if err := db.First(&run, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.scheduled_run.not_found"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+	// 只能取消自己有运行权限的脚本的计划运行
+	if !requireScriptsRole(c, []string{run.ScriptID}, models.RoleOperator) {
+		return
+	}
+
+	if err := scheduler.Cancel(id); err != nil {
+		if errors.Is(err, scheduler.ErrNotPending) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": i18n.T(c, "error.scheduled_run.not_pending"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.transaction_failed"),
+		})
+		return
+	}
+	before := run
+	db.First(&run, "id = ?", id)
+	audit.SetChange(c, id, before, run)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.scheduled_run.cancelled"),
+	})
+}
+
+// deleteScheduledRuns 取消并删除脚本的全部计划运行（内部函数）
+func deleteScheduledRuns(scriptID string) {
+	scheduler.CancelScript(scriptID)
+	database.GetDB().Where("script_id = ?", scriptID).Delete(&models.ScheduledRun{})
+}
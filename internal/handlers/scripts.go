@@ -1,16 +1,26 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
 	"hook-panel/internal/pkg/database"
 	"hook-panel/internal/pkg/executor"
 	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/gitstore"
 	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/params"
+	"hook-panel/internal/pkg/runner"
+	"hook-panel/internal/pkg/scheduler"
+	"hook-panel/internal/pkg/versions"
+	"hook-panel/internal/pkg/watcher"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -39,12 +49,15 @@ func GetScripts(c *gin.Context) {
 	// 执行器筛选参数
 	executorParam := c.Query("executor")
 
+	// 分组筛选参数
+	groupParam := c.Query("group")
+
 	// 排序参数
 	sortField := c.DefaultQuery("sort_field", "created_at")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
 
 	// 构建查询
-	query := db.Model(&models.Script{})
+	query := scopeScripts(c, db.Model(&models.Script{}), "id")
 	if search != "" {
 		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
 	}
@@ -63,6 +76,11 @@ func GetScripts(c *gin.Context) {
 		query = query.Where("executor = ?", executorParam)
 	}
 
+	// 添加分组筛选
+	if groupParam != "" {
+		query = query.Where("group_name = ?", groupParam)
+	}
+
 	// 获取总数
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -85,6 +103,11 @@ func GetScripts(c *gin.Context) {
 		return
 	}
 
+	// 填充下次定时触发时间
+	for i := range scripts {
+		scripts[i].NextRunAt = scheduler.NextRun(scripts[i].ID)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data":     scripts,
 		"total":    total,
@@ -121,6 +144,8 @@ func GetScript(c *gin.Context) {
 		return
 	}
 
+	script.NextRunAt = scheduler.NextRun(script.ID)
+
 	response := models.ScriptResponse{
 		Script:  script,
 		Content: content,
@@ -139,12 +164,97 @@ func CreateScript(c *gin.Context) {
 		return
 	}
 
+	createScript(c, req)
+}
+
+// createScript 校验并创建脚本，写入响应
+func createScript(c *gin.Context, req models.ScriptCreateRequest) {
+	// 需要目标分组的编辑权限
+	if !requireScriptRole(c, "", req.Group, models.RoleEditor) {
+		return
+	}
+
+	// 校验参数定义
+	if err := params.ValidateDefinitions(req.Parameters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.script.invalid_parameters", err.Error()),
+		})
+		return
+	}
+
+	// 校验环境变量
+	if err := params.ValidateEnv(req.Env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.script.invalid_env", err.Error()),
+		})
+		return
+	}
+
+	// 校验重试策略
+	retry := models.DefaultRetryPolicy()
+	if req.Retry != nil {
+		if err := req.Retry.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_retry", err.Error()),
+			})
+			return
+		}
+		retry = *req.Retry
+	}
+
+	// 校验定时配置
+	var schedule models.CronSchedule
+	if req.Schedule != nil {
+		if err := scheduler.ValidateSchedule(*req.Schedule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_schedule", err.Error()),
+			})
+			return
+		}
+		schedule = *req.Schedule
+		schedule.LastFiredAt = nil
+	}
+
+	// 校验链式触发配置
+	var chain models.ScriptChain
+	if req.Chain != nil {
+		if err := runner.ValidateChain(*req.Chain, ""); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_chain", err.Error()),
+			})
+			return
+		}
+		if !requireScriptsRole(c, req.Chain.Targets(), models.RoleOperator) {
+			return
+		}
+		chain = *req.Chain
+	}
+
+	// 校验文件监听配置
+	var watch models.FileWatch
+	if req.Watch != nil {
+		if err := watcher.Validate(*req.Watch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_watch", err.Error()),
+			})
+			return
+		}
+		watch = *req.Watch
+	}
+
 	// 创建脚本记录
 	script := models.Script{
 		Name:        req.Name,
 		Description: req.Description,
+		Group:       req.Group,
 		Executor:    req.Executor,
 		Enabled:     req.Enabled,
+		Parameters:  req.Parameters,
+		Env:         req.Env,
+		Retry:       retry,
+		Schedule:    schedule,
+		Chain:       chain,
+		Watch:       watch,
 	}
 
 	db := database.GetDB()
@@ -167,6 +277,24 @@ func CreateScript(c *gin.Context) {
 		}
 	}
 
+	// 记录初始版本，启用 Git 存储时提交到仓库
+	if _, err := versions.Record(script.ID, req.Content, script.Executor, requestAuthor(c), req.Message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.script.save_content_failed"),
+		})
+		return
+	}
+	if err := gitstore.Commit(script.ID, requestAuthor(c), commitMessage(req.Message, "Create script "+script.Name)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.storage.commit_failed", err.Error()),
+		})
+		return
+	}
+
+	scheduler.Reload(script.ID)
+	watcher.Reload(script.ID)
+	audit.SetChange(c, script.ID, nil, models.ScriptResponse{Script: script, Content: req.Content})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": i18n.T(c, "success.script.created"),
 		"data":    script,
@@ -191,6 +319,65 @@ func UpdateScript(c *gin.Context) {
 		return
 	}
 
+	// 校验参数定义
+	if req.Parameters != nil {
+		if err := params.ValidateDefinitions(*req.Parameters); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_parameters", err.Error()),
+			})
+			return
+		}
+	}
+
+	if req.Env != nil {
+		if err := params.ValidateEnv(*req.Env); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_env", err.Error()),
+			})
+			return
+		}
+	}
+
+	if req.Retry != nil {
+		if err := req.Retry.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_retry", err.Error()),
+			})
+			return
+		}
+	}
+
+	if req.Schedule != nil {
+		if err := scheduler.ValidateSchedule(*req.Schedule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_schedule", err.Error()),
+			})
+			return
+		}
+	}
+
+	if req.Chain != nil {
+		if err := runner.ValidateChain(*req.Chain, scriptID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_chain", err.Error()),
+			})
+			return
+		}
+		// 链式触发会运行后续脚本，需要在每个后续脚本上拥有 operator 权限
+		if !requireScriptsRole(c, req.Chain.Targets(), models.RoleOperator) {
+			return
+		}
+	}
+
+	if req.Watch != nil {
+		if err := watcher.Validate(*req.Watch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.script.invalid_watch", err.Error()),
+			})
+			return
+		}
+	}
+
 	db := database.GetDB()
 	var script models.Script
 	if err := db.First(&script, "id = ?", scriptID).Error; err != nil {
@@ -199,6 +386,15 @@ func UpdateScript(c *gin.Context) {
 		})
 		return
 	}
+	if rejectManaged(c, script) {
+		return
+	}
+
+	// 移动到其他分组时需要目标分组的编辑权限
+	if req.Group != nil && *req.Group != script.Group && !requireScriptRole(c, "", *req.Group, models.RoleEditor) {
+		return
+	}
+	before := scriptSnapshot(script)
 
 	// 更新字段
 	updates := make(map[string]interface{})
@@ -208,12 +404,49 @@ func UpdateScript(c *gin.Context) {
 	if req.Description != "" {
 		updates["description"] = req.Description
 	}
+	if req.Group != nil {
+		updates["group_name"] = *req.Group
+	}
 	if req.Executor != "" {
 		updates["executor"] = req.Executor
 	}
 	if req.Enabled != nil {
 		updates["enabled"] = *req.Enabled
 	}
+	if req.Parameters != nil {
+		updates["parameters"] = *req.Parameters
+	}
+	if req.Env != nil {
+		updates["env"] = *req.Env
+	}
+	if req.Retry != nil {
+		updates["retry_max_attempts"] = req.Retry.MaxAttempts
+		updates["retry_backoff"] = req.Retry.Backoff
+		updates["retry_delay"] = req.Retry.Delay
+		updates["retry_max_delay"] = req.Retry.MaxDelay
+		updates["retry_exit_codes"] = req.Retry.ExitCodes
+		updates["retry_on_timeout"] = req.Retry.OnTimeout
+	}
+	if req.Schedule != nil {
+		updates["schedule_cron"] = req.Schedule.Cron
+		updates["schedule_timezone"] = req.Schedule.Timezone
+		updates["schedule_missed_run"] = scheduleMissedRun(*req.Schedule)
+		// 定时配置变更后重新记录调度基准时间
+		if req.Schedule.Cron != script.Schedule.Cron || req.Schedule.Timezone != script.Schedule.Timezone {
+			updates["schedule_last_fired_at"] = nil
+		}
+	}
+	if req.Chain != nil {
+		updates["chain_on_success"] = req.Chain.OnSuccess
+		updates["chain_on_failure"] = req.Chain.OnFailure
+		updates["chain_always"] = req.Chain.Always
+	}
+	if req.Watch != nil {
+		updates["watch_path"] = req.Watch.Path
+		updates["watch_pattern"] = req.Watch.Pattern
+		updates["watch_recursive"] = req.Watch.Recursive
+		updates["watch_debounce"] = req.Watch.Debounce
+	}
 
 	if len(updates) > 0 {
 		if err := db.Model(&script).Updates(updates).Error; err != nil {
@@ -234,6 +467,30 @@ func UpdateScript(c *gin.Context) {
 		}
 	}
 
+	// 内容或执行器变更时记录新版本
+	if req.Content != "" || req.Executor != "" {
+		if err := recordVersion(c, scriptID, req.Message); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.script.save_content_failed"),
+			})
+			return
+		}
+	}
+	if req.Content != "" {
+		if err := gitstore.Commit(scriptID, requestAuthor(c), commitMessage(req.Message, "Update script "+script.Name)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.storage.commit_failed", err.Error()),
+			})
+			return
+		}
+	}
+
+	scheduler.Reload(scriptID)
+	watcher.Reload(scriptID)
+
+	db.First(&script, "id = ?", scriptID)
+	audit.SetChange(c, scriptID, before, scriptSnapshot(script))
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": i18n.T(c, "success.script.updated"),
 	})
@@ -257,6 +514,11 @@ func DeleteScript(c *gin.Context) {
 		})
 		return
 	}
+	if rejectManaged(c, script) {
+		return
+	}
+
+	before := scriptSnapshot(script)
 
 	// 删除数据库记录
 	if err := db.Delete(&script).Error; err != nil {
@@ -271,6 +533,9 @@ func DeleteScript(c *gin.Context) {
 		// 记录错误但不影响响应
 		// 可以考虑添加日志记录
 	}
+	if err := gitstore.Commit(scriptID, requestAuthor(c), "Delete script "+script.Name); err != nil {
+		log.Printf("Failed to commit deletion of script %s: %v", scriptID, err)
+	}
 
 	// 删除脚本日志文件
 	if err := file.DeleteScriptLog(scriptID); err != nil {
@@ -278,6 +543,30 @@ func DeleteScript(c *gin.Context) {
 		// 可以考虑添加日志记录
 	}
 
+	// 删除编译缓存
+	if err := executor.CleanBuilds(scriptID); err != nil {
+		// 记录错误但不影响响应
+		// 可以考虑添加日志记录
+	}
+
+	// 删除运行记录
+	deleteScriptRuns(scriptID)
+
+	// 移除定时任务、计划运行和文件监听
+	scheduler.Remove(scriptID)
+	deleteScheduledRuns(scriptID)
+	watcher.Remove(scriptID)
+
+	// 移除其他脚本对该脚本的链式引用
+	removeChainReferences(scriptID)
+
+	// 删除历史版本
+	versions.Delete(scriptID)
+
+	// 删除针对该脚本的授权
+	db.Where("script_id = ?", scriptID).Delete(&models.ScriptPermission{})
+	audit.SetChange(c, scriptID, before, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": i18n.T(c, "success.script.deleted"),
 	})
@@ -301,8 +590,12 @@ func ToggleScript(c *gin.Context) {
 		})
 		return
 	}
+	if rejectManaged(c, script) {
+		return
+	}
 
 	// 切换状态
+	before := script
 	newStatus := !script.Enabled
 	if err := db.Model(&script).Update("enabled", newStatus).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -310,6 +603,10 @@ func ToggleScript(c *gin.Context) {
 		})
 		return
 	}
+	audit.SetChange(c, scriptID, before, script)
+
+	scheduler.Reload(scriptID)
+	watcher.Reload(scriptID)
 
 	statusText := i18n.T(c, "status.enabled")
 	if !newStatus {
@@ -332,42 +629,32 @@ func ExecuteScript(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDB()
-	var script models.Script
-	if err := db.First(&script, "id = ?", scriptID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": i18n.T(c, "error.script.not_found"),
-		})
-		return
-	}
-
-	// 检查脚本是否启用
-	if !script.Enabled {
+	// 请求体可选，包含参数值和标准输入
+	var req models.ScriptExecuteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": i18n.T(c, "error.webhook.script_not_found"),
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
 		})
 		return
 	}
 
-	// 读取脚本内容
-	content, err := file.ReadScriptContent(scriptID)
+	// 加载脚本并校验参数
+	job, err := runner.Prepare(scriptID, runner.Request{
+		Trigger: models.TriggerManual,
+		Params:  req.Params,
+		Stdin:   req.Stdin,
+		NoRetry: true, // 手动执行只尝试一次，重试会让请求一直等待
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": i18n.T(c, "error.script.load_content_failed"),
-		})
-		return
-	}
-
-	if strings.TrimSpace(content) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": i18n.T(c, "error.script.load_content_failed"),
+		status, message := runErrorResponse(c, err)
+		c.JSON(status, gin.H{
+			"error": message,
 		})
 		return
 	}
 
-	// 创建执行器并执行脚本
-	scriptExecutor := executor.NewScriptExecutor(60 * time.Second) // 60秒超时
-	result, err := scriptExecutor.ExecuteScript(scriptID, content, script.Executor)
+	// 执行脚本
+	result, err := job.Run()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": i18n.T(c, "error.script.execute_failed") + ": " + err.Error(),
@@ -375,15 +662,6 @@ func ExecuteScript(c *gin.Context) {
 		return
 	}
 
-	// 更新调用统计
-	now := time.Now()
-	db.Model(&models.Script{}).
-		Where("id = ?", scriptID).
-		Updates(map[string]interface{}{
-			"call_count":   gorm.Expr("call_count + 1"),
-			"last_call_at": now,
-		})
-
 	// 返回执行结果
 	c.JSON(http.StatusOK, gin.H{
 		"message": i18n.T(c, "success.webhook.executed"),
@@ -391,6 +669,22 @@ func ExecuteScript(c *gin.Context) {
 	})
 }
 
+// runErrorResponse 将运行准备阶段的错误转换为响应状态码和提示信息
+func runErrorResponse(c *gin.Context, err error) (int, string) {
+	switch {
+	case errors.Is(err, runner.ErrScriptNotFound):
+		return http.StatusNotFound, i18n.T(c, "error.script.not_found")
+	case errors.Is(err, runner.ErrScriptDisabled):
+		return http.StatusBadRequest, i18n.T(c, "error.webhook.script_not_found")
+	case errors.Is(err, runner.ErrEmptyContent):
+		return http.StatusBadRequest, i18n.T(c, "error.script.load_content_failed")
+	case errors.Is(err, runner.ErrInvalidParams):
+		return http.StatusBadRequest, i18n.T(c, "error.script.invalid_parameters", strings.TrimPrefix(err.Error(), runner.ErrInvalidParams.Error()+": "))
+	default:
+		return http.StatusInternalServerError, i18n.T(c, "error.script.load_content_failed")
+	}
+}
+
 // GetScriptLogs 获取脚本执行日志
 func GetScriptLogs(c *gin.Context) {
 	scriptID := c.Param("id")
@@ -458,6 +752,127 @@ func ClearScriptLogs(c *gin.Context) {
 	})
 }
 
+// scriptSnapshot 脚本及其内容，用于记录审计日志
+func scriptSnapshot(script models.Script) models.ScriptResponse {
+	content, _ := file.ReadScriptContent(script.ID)
+	return models.ScriptResponse{Script: script, Content: content}
+}
+
+// requireScriptRole 校验当前身份在脚本或分组上的角色，无权限时直接写入错误响应
+func requireScriptRole(c *gin.Context, scriptID, group, required string) bool {
+	allowed, err := auth.CurrentIdentity(c).CanScript(scriptID, group, required)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": i18n.T(c, "error.auth.forbidden_role", required),
+		})
+		return false
+	}
+	return true
+}
+
+// requireScriptIDRole 查询脚本所在分组后校验当前身份的角色，脚本已删除时只按脚本 ID 校验，
+// 用于运行记录等只保存脚本 ID 的对象，无权限时直接写入错误响应
+func requireScriptIDRole(c *gin.Context, scriptID, required string) bool {
+	var script models.Script
+	err := database.GetDB().Select("id", "group_name").First(&script, "id = ?", scriptID).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return false
+	}
+	return requireScriptRole(c, scriptID, script.Group, required)
+}
+
+// requireScriptsRole 校验当前身份在每个脚本上都拥有 required 角色，不存在的脚本跳过，
+// 用于工作流和计划运行等引用脚本的对象，无权限时直接写入错误响应
+func requireScriptsRole(c *gin.Context, scriptIDs []string, required string) bool {
+	if len(scriptIDs) == 0 {
+		return true
+	}
+	var scripts []models.Script
+	if err := database.GetDB().Select("id", "group_name").Where("id IN ?", scriptIDs).Find(&scripts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return false
+	}
+	for _, script := range scripts {
+		if !requireScriptRole(c, script.ID, script.Group, required) {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeScripts 限定脚本的 API 令牌只能看到授权脚本的记录，column 为脚本 ID 所在的列
+func scopeScripts(c *gin.Context, query *gorm.DB, column string) *gorm.DB {
+	if ids := auth.CurrentIdentity(c).ScriptScope(); ids != nil {
+		return query.Where(column+" IN ?", ids)
+	}
+	return query
+}
+
+// rejectManaged 配置文件管理的脚本在 API 中只读，是受管脚本时直接写入错误响应
+func rejectManaged(c *gin.Context, script models.Script) bool {
+	if !script.Managed {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": i18n.T(c, "error.script.managed"),
+	})
+	return true
+}
+
+// scheduleMissedRun 获取错过触发的处理策略，默认跳过
+func scheduleMissedRun(schedule models.CronSchedule) string {
+	if schedule.MissedRun == "" {
+		return models.MissedRunSkip
+	}
+	return schedule.MissedRun
+}
+
+// commitMessage 获取提交说明，未填写时使用默认说明
+func commitMessage(message, fallback string) string {
+	if message != "" {
+		return message
+	}
+	return fallback
+}
+
+// removeChainReferences 从其他脚本的链式配置中移除已删除的脚本（内部函数）
+func removeChainReferences(scriptID string) {
+	db := database.GetDB()
+	pattern := "%\"" + scriptID + "\"%"
+
+	var scripts []models.Script
+	db.Where("chain_on_success LIKE ? OR chain_on_failure LIKE ? OR chain_always LIKE ?", pattern, pattern, pattern).Find(&scripts)
+	for _, script := range scripts {
+		db.Model(&script).Updates(map[string]interface{}{
+			"chain_on_success": withoutID(script.Chain.OnSuccess, scriptID),
+			"chain_on_failure": withoutID(script.Chain.OnFailure, scriptID),
+			"chain_always":     withoutID(script.Chain.Always, scriptID),
+		})
+	}
+}
+
+// withoutID 返回去掉指定 ID 后的列表
+func withoutID(list models.StringList, id string) models.StringList {
+	result := models.StringList{}
+	for _, item := range list {
+		if item != id {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 // buildOrderBy 构建排序字符串
 func buildOrderBy(field, order string) string {
 	// 允许的排序字段映射（前端字段名 -> 数据库字段名）
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/gitstore"
+	"hook-panel/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PullStorage 从远程 Git 仓库拉取脚本，有冲突时不做修改并返回冲突文件
+func PullStorage(c *gin.Context) {
+	result, err := gitstore.Pull()
+	if err != nil {
+		switch {
+		case errors.Is(err, gitstore.ErrDisabled):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.storage.disabled"),
+			})
+		case errors.Is(err, gitstore.ErrNoRemote):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.storage.no_remote"),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.storage.pull_failed", err.Error()),
+			})
+		}
+		return
+	}
+
+	if len(result.Conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": i18n.T(c, "error.storage.conflict", len(result.Conflicts)),
+			"data":  result,
+		})
+		return
+	}
+
+	audit.SetChange(c, "storage", gin.H{"head": result.Previous}, gin.H{
+		"head":    result.Head,
+		"updated": result.Updated,
+		"removed": result.Removed,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.storage.pulled", len(result.Updated)),
+		"data":    result,
+	})
+}
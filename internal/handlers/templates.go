@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTemplates 获取模板列表（内置模板和用户保存的模板）
+func GetTemplates(c *gin.Context) {
+	list, err := templates.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.template.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// GetTemplate 获取单个模板
+func GetTemplate(c *gin.Context) {
+	tpl, ok := findTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, tpl)
+}
+
+// CreateTemplate 保存模板
+func CreateTemplate(c *gin.Context) {
+	var req models.TemplateCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	if err := templates.Validate(req.Content, req.Parameters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.template.invalid", err.Error()),
+		})
+		return
+	}
+
+	tpl := models.ScriptTemplate{
+		Name:        req.Name,
+		Description: req.Description,
+		Executor:    req.Executor,
+		Content:     req.Content,
+		Parameters:  req.Parameters,
+	}
+	if err := database.GetDB().Create(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.template.create_failed"),
+		})
+		return
+	}
+	audit.SetChange(c, tpl.ID, nil, tpl)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "success.template.created"),
+		"data":    tpl,
+	})
+}
+
+// UpdateTemplate 更新用户保存的模板
+func UpdateTemplate(c *gin.Context) {
+	var req models.TemplateUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	tpl, ok := findSavedTemplate(c)
+	if !ok {
+		return
+	}
+	before := *tpl
+
+	// 内容和参数需要一起校验
+	content, defs := tpl.Content, tpl.Parameters
+	if req.Content != "" {
+		content = req.Content
+	}
+	if req.Parameters != nil {
+		defs = *req.Parameters
+	}
+	if err := templates.Validate(content, defs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.template.invalid", err.Error()),
+		})
+		return
+	}
+
+	// 更新字段
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+	if req.Executor != "" {
+		updates["executor"] = req.Executor
+	}
+	if req.Content != "" {
+		updates["content"] = req.Content
+	}
+	if req.Parameters != nil {
+		updates["parameters"] = *req.Parameters
+	}
+
+	if len(updates) > 0 {
+		if err := database.GetDB().Model(tpl).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.template.update_failed"),
+			})
+			return
+		}
+	}
+	database.GetDB().First(tpl, "id = ?", tpl.ID)
+	audit.SetChange(c, tpl.ID, before, tpl)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.template.updated"),
+	})
+}
+
+// DeleteTemplate 删除用户保存的模板
+func DeleteTemplate(c *gin.Context) {
+	tpl, ok := findSavedTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Delete(tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.template.delete_failed"),
+		})
+		return
+	}
+	audit.SetChange(c, tpl.ID, tpl, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.template.deleted"),
+	})
+}
+
+// CreateScriptFromTemplate 按参数渲染模板并创建脚本
+func CreateScriptFromTemplate(c *gin.Context) {
+	var req models.ScriptFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	tpl, ok := findTemplate(c, req.TemplateID)
+	if !ok {
+		return
+	}
+
+	content, err := templates.Render(tpl, req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.template.render_failed", err.Error()),
+		})
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = tpl.Name
+	}
+	createScript(c, models.ScriptCreateRequest{
+		Name:        name,
+		Description: req.Description,
+		Content:     content,
+		Executor:    tpl.Executor,
+		Enabled:     req.Enabled,
+		Message:     "Create from template " + tpl.Name,
+	})
+}
+
+// findTemplate 查找模板，不存在时直接写入错误响应
+func findTemplate(c *gin.Context, id string) (*models.ScriptTemplate, bool) {
+	tpl, err := templates.Get(id)
+	if err != nil {
+		if errors.Is(err, templates.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.template.not_found"),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return nil, false
+	}
+	return tpl, true
+}
+
+// findSavedTemplate 查找用户保存的模板，内置模板不能修改
+func findSavedTemplate(c *gin.Context) (*models.ScriptTemplate, bool) {
+	id := c.Param("id")
+	if templates.IsBuiltIn(id) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": i18n.T(c, "error.template.builtin"),
+		})
+		return nil, false
+	}
+	return findTemplate(c, id)
+}
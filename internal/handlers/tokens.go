@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetAPITokens 获取 API 令牌列表
+func GetAPITokens(c *gin.Context) {
+	var tokens []models.APIToken
+	if err := database.GetDB().Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.token.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  tokens,
+		"total": len(tokens),
+	})
+}
+
+// GetAPIToken 获取单个 API 令牌
+func GetAPIToken(c *gin.Context) {
+	token, ok := findAPIToken(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": token,
+	})
+}
+
+// CreateAPIToken 创建 API 令牌，明文令牌只在响应中返回一次
+func CreateAPIToken(c *gin.Context) {
+	var req models.TokenCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	if err := auth.ValidateTokenScope(req.Scopes, req.ScriptIDs, req.ExpiresAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.token.invalid", err.Error()),
+		})
+		return
+	}
+
+	plain, token, err := auth.CreateAPIToken(req, requestAuthor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.token.create_failed"),
+		})
+		return
+	}
+
+	audit.SetChange(c, token.ID, nil, token)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "success.token.created"),
+		"data": models.TokenCreateResponse{
+			APIToken: *token,
+			Token:    plain,
+		},
+	})
+}
+
+// UpdateAPIToken 更新 API 令牌的名称、权限范围、脚本限制和过期时间
+func UpdateAPIToken(c *gin.Context) {
+	var req models.TokenUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	token, ok := findAPIToken(c)
+	if !ok {
+		return
+	}
+
+	before := *token
+
+	var scriptIDs []string
+	if req.ScriptIDs != nil {
+		scriptIDs = *req.ScriptIDs
+	}
+	if err := auth.ValidateTokenScope(req.Scopes, scriptIDs, req.ExpiresAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.token.invalid", err.Error()),
+		})
+		return
+	}
+
+	// 更新字段
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Scopes != nil {
+		updates["scopes"] = models.StringList(req.Scopes)
+	}
+	if req.ScriptIDs != nil {
+		updates["script_ids"] = models.StringList(scriptIDs)
+	}
+	if req.NoExpiry {
+		updates["expires_at"] = nil
+	} else if req.ExpiresAt != nil {
+		updates["expires_at"] = *req.ExpiresAt
+	}
+
+	db := database.GetDB()
+	if len(updates) > 0 {
+		if err := db.Model(token).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.token.update_failed"),
+			})
+			return
+		}
+	}
+	db.First(token, "id = ?", token.ID)
+	audit.SetChange(c, token.ID, before, token)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.token.updated"),
+		"data":    token,
+	})
+}
+
+// DeleteAPIToken 吊销 API 令牌
+func DeleteAPIToken(c *gin.Context) {
+	token, ok := findAPIToken(c)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Delete(token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.token.delete_failed"),
+		})
+		return
+	}
+
+	audit.SetChange(c, token.ID, token, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.token.deleted"),
+	})
+}
+
+// findAPIToken 根据路由参数查找 API 令牌，不存在时直接写入错误响应
+func findAPIToken(c *gin.Context) (*models.APIToken, bool) {
+	var token models.APIToken
+	if err := database.GetDB().First(&token, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.token.not_found"),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return nil, false
+	}
+	return &token, true
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/lockout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTOTP 开始绑定两步验证，返回密钥和用于生成二维码的 otpauth 链接
+func SetupTOTP(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": i18n.T(c, "error.auth.totp_already_enabled"),
+		})
+		return
+	}
+
+	secret, uri, err := auth.BeginTOTPSetup(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.update_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": models.TOTPSetupResponse{
+			Secret: secret,
+			URI:    uri,
+		},
+	})
+}
+
+// EnableTOTP 使用验证器应用生成的验证码确认绑定，返回恢复码，恢复码只显示这一次
+func EnableTOTP(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	codes, err := auth.EnableTOTP(user, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrTOTPNotPending):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.auth.totp_not_pending"),
+			})
+		case errors.Is(err, auth.ErrInvalidTOTP):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.auth.invalid_totp"),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.user.update_failed"),
+			})
+		}
+		return
+	}
+	audit.SetChange(c, user.ID, gin.H{"totp_enabled": false}, gin.H{"totp_enabled": true})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        i18n.T(c, "success.auth.totp_enabled"),
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP 关闭两步验证，需要当前密码和验证码（或恢复码）
+func DisableTOTP(c *gin.Context) {
+	var req models.TOTPDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.auth.totp_not_enabled"),
+		})
+		return
+	}
+	if !lockout.Guard(c) {
+		return
+	}
+	if !auth.CheckPassword(user, req.Password) {
+		lockout.Fail(c, lockout.ScopeLogin)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.auth.wrong_password"),
+		})
+		return
+	}
+	lockout.Reset(c.ClientIP(), lockout.ScopeLogin)
+	if !verifySecondFactor(c, user, req.Code) {
+		return
+	}
+
+	if err := auth.DisableTOTP(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.update_failed"),
+		})
+		return
+	}
+	audit.SetChange(c, user.ID, gin.H{"totp_enabled": true}, gin.H{"totp_enabled": false})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.auth.totp_disabled"),
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，需要验证码（或恢复码），旧的恢复码全部失效
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.auth.totp_not_enabled"),
+		})
+		return
+	}
+	if !verifySecondFactor(c, user, req.Code) {
+		return
+	}
+
+	codes, err := auth.RegenerateRecoveryCodes(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.update_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        i18n.T(c, "success.auth.recovery_codes_regenerated"),
+		"recovery_codes": codes,
+	})
+}
+
+// sessionUser 获取当前登录的用户，使用访问密钥或 API 令牌时直接写入错误响应
+func sessionUser(c *gin.Context) (*models.User, bool) {
+	identity := auth.CurrentIdentity(c)
+	if identity == nil || identity.User == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.auth.no_session"),
+		})
+		return nil, false
+	}
+	return identity.User, true
+}
+
+// verifySecondFactor 校验验证码或恢复码，失败时直接写入错误响应
+func verifySecondFactor(c *gin.Context, user *models.User, code string) bool {
+	if err := auth.VerifySecondFactor(user, code); err != nil {
+		if errors.Is(err, auth.ErrInvalidTOTP) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.auth.invalid_totp"),
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return false
+	}
+	return true
+}
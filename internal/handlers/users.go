@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"net/http"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/oidc"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// userChange 审计日志中记录的用户状态，密码哈希不会输出，重置密码时单独标记
+type userChange struct {
+	models.User
+	PasswordReset bool `json:"password_reset,omitempty"`
+}
+
+// GetUsers 获取用户列表
+func GetUsers(c *gin.Context) {
+	var users []models.User
+	if err := database.GetDB().Order("created_at ASC").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  users,
+		"total": len(users),
+	})
+}
+
+// CreateUser 创建用户
+func CreateUser(c *gin.Context) {
+	var req models.UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var count int64
+	if err := db.Model(&models.User{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": i18n.T(c, "error.user.exists", req.Username),
+		})
+		return
+	}
+
+	var hash string
+	if req.Password != "" {
+		var ok bool
+		if hash, ok = hashPassword(c, req.Password); !ok {
+			return
+		}
+	}
+
+	role := req.Role
+	if role == "" {
+		role = models.RoleViewer
+	}
+	user := models.User{
+		Username:     req.Username,
+		DisplayName:  req.DisplayName,
+		PasswordHash: hash,
+		Role:         role,
+	}
+	if req.OIDCSubject != "" {
+		link, ok := oidcLink(c, "", req.OIDCSubject)
+		if !ok {
+			return
+		}
+		user.OIDCIssuer, user.OIDCSubject = link["oidc_issuer"].(string), req.OIDCSubject
+	}
+	if err := db.Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.create_failed"),
+		})
+		return
+	}
+
+	audit.SetChange(c, user.ID, nil, user)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "success.user.created"),
+		"data":    user,
+	})
+}
+
+// UpdateUser 更新用户，重置密码或禁用时注销该用户的全部会话
+func UpdateUser(c *gin.Context) {
+	var req models.UserUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	before := *user
+
+	// 更新字段
+	updates := make(map[string]interface{})
+	if req.DisplayName != "" {
+		updates["display_name"] = req.DisplayName
+	}
+	if req.Role != "" {
+		updates["role"] = req.Role
+	}
+	if req.Disabled != nil {
+		updates["disabled"] = *req.Disabled
+	}
+	if req.Password != "" {
+		hash, ok := hashPassword(c, req.Password)
+		if !ok {
+			return
+		}
+		updates["password_hash"] = hash
+	}
+	if req.OIDCSubject != nil {
+		link, ok := oidcLink(c, user.ID, *req.OIDCSubject)
+		if !ok {
+			return
+		}
+		for key, value := range link {
+			updates[key] = value
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := database.GetDB().Model(user).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.user.update_failed"),
+			})
+			return
+		}
+	}
+	if req.ResetTOTP {
+		if err := auth.DisableTOTP(user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.user.update_failed"),
+			})
+			return
+		}
+	}
+	if req.Password != "" || (req.Disabled != nil && *req.Disabled) {
+		auth.DeleteUserSessions(user.ID, "")
+	}
+	database.GetDB().First(user, "id = ?", user.ID)
+	audit.SetChange(c, user.ID, userChange{User: before}, userChange{User: *user, PasswordReset: req.Password != ""})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.user.updated"),
+	})
+}
+
+// DeleteUser 删除用户及其会话
+func DeleteUser(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Delete(user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.delete_failed"),
+		})
+		return
+	}
+	auth.DeleteUserSessions(user.ID, "")
+	database.GetDB().Where("user_id = ?", user.ID).Delete(&models.ScriptPermission{})
+	audit.SetChange(c, user.ID, user, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.user.deleted"),
+	})
+}
+
+// GetUserPermissions 获取用户在脚本和分组上的授权
+func GetUserPermissions(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var permissions []models.ScriptPermission
+	if err := database.GetDB().Where("user_id = ?", user.ID).Order("created_at ASC").Find(&permissions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"role": user.Role,
+		"data": permissions,
+	})
+}
+
+// UpdateUserPermissions 替换用户在脚本和分组上的全部授权
+func UpdateUserPermissions(c *gin.Context) {
+	var req models.PermissionsUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var before []models.ScriptPermission
+	database.GetDB().Where("user_id = ?", user.ID).Order("created_at ASC").Find(&before)
+
+	if err := auth.ValidateGrants(req.Permissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.user.invalid_permissions", err.Error()),
+		})
+		return
+	}
+
+	permissions, err := auth.ReplaceGrants(user.ID, req.Permissions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.user.update_failed"),
+		})
+		return
+	}
+
+	audit.SetChange(c, user.ID, gin.H{"permissions": grants(before)}, gin.H{"permissions": grants(permissions)})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.user.permissions_updated"),
+		"data":    permissions,
+	})
+}
+
+// grants 授权列表中用于比较的部分，去掉 ID 和时间
+func grants(permissions []models.ScriptPermission) []models.PermissionGrant {
+	result := make([]models.PermissionGrant, 0, len(permissions))
+	for _, p := range permissions {
+		result = append(result, models.PermissionGrant{ScriptID: p.ScriptID, Group: p.Group, Role: p.Role})
+	}
+	return result
+}
+
+// oidcLink 管理员手动关联单点登录身份，返回需要更新的字段，subject 为空表示取消关联。
+// 未配置身份提供方或该身份已关联其他用户时直接写入错误响应
+func oidcLink(c *gin.Context, userID, subject string) (map[string]interface{}, bool) {
+	if subject == "" {
+		return map[string]interface{}{"oidc_issuer": "", "oidc_subject": ""}, true
+	}
+	issuer := oidc.Issuer()
+	if issuer == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.user.oidc_not_configured"),
+		})
+		return nil, false
+	}
+
+	var linked models.User
+	err := database.GetDB().Where("oidc_issuer = ? AND oidc_subject = ? AND id <> ?", issuer, subject, userID).First(&linked).Error
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": i18n.T(c, "error.user.oidc_linked", linked.Username),
+		})
+		return nil, false
+	}
+	if err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return nil, false
+	}
+	return map[string]interface{}{"oidc_issuer": issuer, "oidc_subject": subject}, true
+}
+
+// findUser 根据路由参数查找用户，不存在时直接写入错误响应
+func findUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := database.GetDB().First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.user.not_found"),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return nil, false
+	}
+	return &user, true
+}
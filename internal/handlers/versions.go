@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/gitstore"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/versions"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetScriptVersions 获取脚本的历史版本（不含内容）
+func GetScriptVersions(c *gin.Context) {
+	scriptID := c.Param("id")
+
+	// 分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	db := database.GetDB()
+	query := db.Model(&models.ScriptVersion{}).Where("script_id = ?", scriptID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.version.get_failed"),
+		})
+		return
+	}
+
+	var list []models.ScriptVersion
+	if err := query.Omit("content").
+		Order("version DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.version.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ScriptVersionListResponse{
+		Data:     list,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// GetScriptVersion 获取指定版本（包含内容）
+func GetScriptVersion(c *gin.Context) {
+	version, ok := findVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
+
+// DiffScriptVersions 生成两个版本之间的统一格式差异，to 默认为当前版本
+func DiffScriptVersions(c *gin.Context) {
+	from, ok := findVersion(c, c.Query("from"))
+	if !ok {
+		return
+	}
+
+	toParam := c.Query("to")
+	if toParam == "" {
+		toParam = strconv.Itoa(versions.Current(c.Param("id")))
+	}
+	to, ok := findVersion(c, toParam)
+	if !ok {
+		return
+	}
+
+	diff, err := versions.Diff(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.version.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Version,
+		"to":   to.Version,
+		"diff": diff,
+	})
+}
+
+// RestoreScriptVersion 将脚本恢复为指定版本的内容，恢复结果作为一个新版本保存
+func RestoreScriptVersion(c *gin.Context) {
+	scriptID := c.Param("id")
+
+	var req models.ScriptVersionRestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	var script models.Script
+	if err := database.GetDB().First(&script, "id = ?", scriptID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": i18n.T(c, "error.script.not_found"),
+		})
+		return
+	}
+	if rejectManaged(c, script) {
+		return
+	}
+
+	version, ok := findVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	before := scriptSnapshot(script)
+
+	if err := file.SaveScriptContent(scriptID, version.Content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.script.save_content_failed"),
+		})
+		return
+	}
+	if err := database.GetDB().Model(&models.Script{}).Where("id = ?", scriptID).Update("executor", version.Executor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.script.update_failed"),
+		})
+		return
+	}
+
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("Restore version %d", version.Version)
+	}
+	restored, err := versions.Record(scriptID, version.Content, version.Executor, requestAuthor(c), message)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.script.save_content_failed"),
+		})
+		return
+	}
+	if err := gitstore.Commit(scriptID, requestAuthor(c), message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.storage.commit_failed", err.Error()),
+		})
+		return
+	}
+	script.Executor = version.Executor
+	audit.SetChange(c, scriptID, before, scriptSnapshot(script))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.version.restored", version.Version),
+		"data":    restored,
+	})
+}
+
+// recordVersion 按脚本当前的内容和执行器记录新版本（内部函数）
+func recordVersion(c *gin.Context, scriptID, message string) error {
+	var script models.Script
+	if err := database.GetDB().First(&script, "id = ?", scriptID).Error; err != nil {
+		return err
+	}
+	content, err := file.ReadScriptContent(scriptID)
+	if err != nil {
+		return err
+	}
+	_, err = versions.Record(scriptID, content, script.Executor, requestAuthor(c), message)
+	return err
+}
+
+// requestAuthor 获取当前请求的操作者，用于版本记录
+func requestAuthor(c *gin.Context) string {
+	if identity := auth.CurrentIdentity(c); identity != nil {
+		return identity.Name()
+	}
+	return auth.KeyIdentityName
+}
+
+// findVersion 加载路径中脚本的指定版本，失败时写入错误响应
+func findVersion(c *gin.Context, param string) (*models.ScriptVersion, bool) {
+	number, err := strconv.Atoi(param)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", "version"),
+		})
+		return nil, false
+	}
+
+	version, err := versions.Get(c.Param("id"), number)
+	if err != nil {
+		if errors.Is(err, versions.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.version.not_found", number),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return nil, false
+	}
+	return version, true
+}
@@ -1,25 +1,32 @@
 package handlers
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"hook-panel/internal/middleware"
 	"hook-panel/internal/models"
 	"hook-panel/internal/pkg/auth"
 	"hook-panel/internal/pkg/database"
-	"hook-panel/internal/pkg/executor"
-	"hook-panel/internal/pkg/file"
 	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/lockout"
+	"hook-panel/internal/pkg/runner"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// maxWebhookPayload 传递给脚本的 webhook 请求体大小上限
+const maxWebhookPayload = 1 << 20
+
 // WebhookHandler 处理 webhook 请求
 func WebhookHandler(c *gin.Context) {
 	startTime := time.Now()
@@ -35,79 +42,45 @@ func WebhookHandler(c *gin.Context) {
 	}
 
 	// 验证签名
-	if !validateWebhookSignature(c, scriptID) {
-		errorMsg := i18n.T(c, "error.webhook.invalid_signature")
-		LogWebhookCall(c, scriptID, http.StatusUnauthorized, time.Since(startTime).Milliseconds(), errorMsg)
-		c.JSON(http.StatusUnauthorized, gin.H{
+	if status, errorMsg := checkWebhookSignature(c, scriptID); status != 0 {
+		LogWebhookCall(c, scriptID, status, time.Since(startTime).Milliseconds(), errorMsg)
+		c.JSON(status, gin.H{
 			"error": errorMsg,
 		})
 		return
 	}
 
-	// 查找脚本
-	db := database.GetDB()
-	var script models.Script
-	if err := db.First(&script, "id = ?", scriptID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			errorMsg := i18n.T(c, "error.webhook.script_not_found")
-			LogWebhookCall(c, scriptID, http.StatusNotFound, time.Since(startTime).Milliseconds(), errorMsg)
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": errorMsg,
-			})
-			return
-		}
-		errorMsg := i18n.T(c, "error.database.query_failed")
-		LogWebhookCall(c, scriptID, http.StatusInternalServerError, time.Since(startTime).Milliseconds(), "Database error: "+err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errorMsg,
-		})
-		return
-	}
-
-	// 检查脚本是否启用
-	if !script.Enabled {
-		errorMsg := i18n.T(c, "error.webhook.script_not_found")
-		LogWebhookCall(c, scriptID, http.StatusBadRequest, time.Since(startTime).Milliseconds(), errorMsg)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errorMsg,
-		})
-		return
-	}
+	// 请求体作为脚本的标准输入，链式触发的后续脚本也会收到同样的内容
+	payload, _ := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookPayload))
+	c.Request.Body = io.NopCloser(bytes.NewReader(payload))
 
-	// 读取脚本内容
-	content, err := file.ReadScriptContent(scriptID)
+	// 加载脚本并校验（webhook 触发时参数使用默认值）
+	job, err := runner.Prepare(scriptID, runner.Request{
+		Trigger: models.TriggerWebhook,
+		Stdin:   string(payload),
+	})
 	if err != nil {
-		errorMsg := i18n.T(c, "error.script.load_content_failed")
-		LogWebhookCall(c, scriptID, http.StatusInternalServerError, time.Since(startTime).Milliseconds(), "Failed to read script content: "+err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errorMsg,
-		})
-		return
-	}
-
-	if strings.TrimSpace(content) == "" {
-		errorMsg := i18n.T(c, "error.script.load_content_failed")
-		LogWebhookCall(c, scriptID, http.StatusBadRequest, time.Since(startTime).Milliseconds(), errorMsg)
-		c.JSON(http.StatusBadRequest, gin.H{
+		status, errorMsg := runErrorResponse(c, err)
+		logMsg := errorMsg
+		switch {
+		case errors.Is(err, runner.ErrScriptNotFound):
+			errorMsg = i18n.T(c, "error.webhook.script_not_found")
+			logMsg = errorMsg
+		case status == http.StatusInternalServerError:
+			logMsg = "Failed to load script: " + err.Error()
+		}
+		LogWebhookCall(c, scriptID, status, time.Since(startTime).Milliseconds(), logMsg)
+		c.JSON(status, gin.H{
 			"error": errorMsg,
 		})
 		return
 	}
-
-	// 更新调用统计
+	script := job.Script
 	now := time.Now()
-	db.Model(&models.Script{}).
-		Where("id = ?", scriptID).
-		Updates(map[string]interface{}{
-			"call_count":   gorm.Expr("call_count + 1"),
-			"last_call_at": now,
-		})
 
 	// 执行脚本（异步执行，不等待结果）
 	go func() {
-		scriptExecutor := executor.NewScriptExecutor(60 * time.Second)
-		_, err := scriptExecutor.ExecuteScript(scriptID, content, script.Executor)
-		if err != nil {
+		if _, err := job.Run(); err != nil {
 			// Record error log, but don't affect webhook response
 			fmt.Printf("Script execution error for %s: %v\n", scriptID, err)
 		}
@@ -128,6 +101,21 @@ func WebhookHandler(c *gin.Context) {
 	})
 }
 
+// checkWebhookSignature 校验签名，失败时返回响应状态码和错误信息。
+// 来源已被锁定时直接拒绝，签名错误计入该来源的失败次数
+func checkWebhookSignature(c *gin.Context, id string) (int, string) {
+	if wait := lockout.Check(c.ClientIP()); wait > 0 {
+		c.Header("Retry-After", lockout.RetryAfter(wait))
+		return http.StatusTooManyRequests, i18n.T(c, "error.auth.locked_out", lockout.RetryAfter(wait))
+	}
+	if !validateWebhookSignature(c, id) {
+		lockout.Fail(c, lockout.ScopeWebhook)
+		return http.StatusUnauthorized, i18n.T(c, "error.webhook.invalid_signature")
+	}
+	// webhook 地址可能是公开的，签名正确不清除该来源的认证失败次数
+	return 0, ""
+}
+
 // validateWebhookSignature 验证 webhook 签名
 func validateWebhookSignature(c *gin.Context, scriptID string) bool {
 	// 从查询参数或 Header 中获取签名
@@ -140,17 +128,25 @@ func validateWebhookSignature(c *gin.Context, scriptID string) bool {
 		return false
 	}
 
-	// 计算期望的签名
-	expectedSignature := generateWebhookSignature(scriptID)
-
-	// 使用恒定时间比较防止时序攻击
-	return hmac.Equal([]byte(signature), []byte(expectedSignature))
+	// 使用恒定时间比较防止时序攻击，轮换宽限期内旧密钥生成的签名仍然有效
+	for i, secretKey := range auth.SecretKeys() {
+		if hmac.Equal([]byte(signature), []byte(webhookSignature(secretKey, scriptID))) {
+			if i > 0 {
+				c.Header(middleware.DeprecatedKeyHeader, "true")
+			}
+			return true
+		}
+	}
+	return false
 }
 
-// generateWebhookSignature 生成 webhook 签名
+// generateWebhookSignature 使用当前密钥生成 webhook 签名
 func generateWebhookSignature(scriptID string) string {
-	secretKey := auth.GetSecretKey()
+	return webhookSignature(auth.GetSecretKey(), scriptID)
+}
 
+// webhookSignature 使用指定密钥计算 webhook 签名
+func webhookSignature(secretKey, scriptID string) string {
 	// 使用 HMAC-SHA256 计算签名
 	h := hmac.New(sha256.New, []byte(secretKey))
 	h.Write([]byte(scriptID))
@@ -188,7 +184,7 @@ func GetWebhookURL(c *gin.Context) {
 	signature := generateWebhookSignature(scriptID)
 
 	// 获取系统配置的域名
-	domain, err := GetConfigValue("system.domain")
+	domain, err := webhookDomain(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": i18n.T(c, "error.config.get_failed"),
@@ -196,6 +192,25 @@ func GetWebhookURL(c *gin.Context) {
 		return
 	}
 
+	// 构建 webhook URL
+	webhookURL := fmt.Sprintf("%s/h/%s?signature=%s", domain, scriptID, signature)
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook_url": webhookURL,
+		"signature":   signature,
+		"script_id":   scriptID,
+		"script_name": script.Name,
+	})
+}
+
+// webhookDomain 获取生成 webhook URL 使用的域名
+func webhookDomain(c *gin.Context) (string, error) {
+	// 获取系统配置的域名
+	domain, err := GetConfigValue("system.domain")
+	if err != nil {
+		return "", err
+	}
+
 	// 如果没有配置域名，使用请求头中的域名作为后备
 	if domain == "" {
 		scheme := "http"
@@ -206,15 +221,5 @@ func GetWebhookURL(c *gin.Context) {
 	}
 
 	// 确保域名不以斜杠结尾
-	domain = strings.TrimSuffix(domain, "/")
-
-	// 构建 webhook URL
-	webhookURL := fmt.Sprintf("%s/h/%s?signature=%s", domain, scriptID, signature)
-
-	c.JSON(http.StatusOK, gin.H{
-		"webhook_url": webhookURL,
-		"signature":   signature,
-		"script_id":   scriptID,
-		"script_name": script.Name,
-	})
+	return strings.TrimSuffix(domain, "/"), nil
 }
@@ -37,7 +37,7 @@ func GetWebhookLogs(c *gin.Context) {
 	}
 
 	db := database.GetDB()
-	query := db.Model(&models.WebhookLog{})
+	query := scopeScripts(c, db.Model(&models.WebhookLog{}), "script_id")
 
 	// 筛选条件
 	if req.ScriptID != "" {
@@ -219,6 +219,43 @@ func ClearWebhookLogs(c *gin.Context) {
 
 // LogWebhookCall 记录 webhook 调用（内部函数）
 func LogWebhookCall(c *gin.Context, scriptID string, status int, responseTime int64, errorMsg string) {
+	headers, body := webhookRequest(c)
+
+	// 创建日志记录
+	log := models.WebhookLog{
+		ScriptID:     scriptID,
+		Method:       c.Request.Method,
+		Headers:      headers,
+		Body:         body,
+		SourceIP:     c.ClientIP(),
+		UserAgent:    c.GetHeader("User-Agent"),
+		Status:       status,
+		ResponseTime: responseTime,
+		ErrorMsg:     errorMsg,
+	}
+	saveWebhookLog(&log)
+}
+
+// LogWorkflowWebhookCall 记录工作流 webhook 调用，与脚本的调用记录分表保存（内部函数）
+func LogWorkflowWebhookCall(c *gin.Context, workflowID string, status int, responseTime int64, errorMsg string) {
+	headers, body := webhookRequest(c)
+
+	log := models.WorkflowWebhookLog{
+		WorkflowID:   workflowID,
+		Method:       c.Request.Method,
+		Headers:      headers,
+		Body:         body,
+		SourceIP:     c.ClientIP(),
+		UserAgent:    c.GetHeader("User-Agent"),
+		Status:       status,
+		ResponseTime: responseTime,
+		ErrorMsg:     errorMsg,
+	}
+	saveWebhookLog(&log)
+}
+
+// webhookRequest 获取 webhook 请求的请求头和请求体
+func webhookRequest(c *gin.Context) (string, string) {
 	// 获取请求头
 	headers, _ := json.Marshal(c.Request.Header)
 
@@ -232,30 +269,14 @@ func LogWebhookCall(c *gin.Context, scriptID string, status int, responseTime in
 			c.Request.Body = io.NopCloser(strings.NewReader(body))
 		}
 	}
+	return string(headers), body
+}
 
-	// 获取客户端IP
-	clientIP := c.ClientIP()
-
-	// 获取User-Agent
-	userAgent := c.GetHeader("User-Agent")
-
-	// 创建日志记录
-	log := models.WebhookLog{
-		ScriptID:     scriptID,
-		Method:       c.Request.Method,
-		Headers:      string(headers),
-		Body:         body,
-		SourceIP:     clientIP,
-		UserAgent:    userAgent,
-		Status:       status,
-		ResponseTime: responseTime,
-		ErrorMsg:     errorMsg,
-	}
-
-	// 异步保存日志，不影响主流程
+// saveWebhookLog 异步保存日志，不影响主流程
+func saveWebhookLog(log interface{}) {
 	go func() {
 		db := database.GetDB()
-		if err := db.Create(&log).Error; err != nil {
+		if err := db.Create(log).Error; err != nil {
 			// 记录错误但不影响主流程
 			println("Failed to save webhook log:", err.Error())
 		}
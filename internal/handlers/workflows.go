@@ -0,0 +1,492 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/executor"
+	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/workflow"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetWorkflows 获取工作流列表
+func GetWorkflows(c *gin.Context) {
+	db := database.GetDB()
+
+	// 分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := db.Model(&models.Workflow{})
+	if search := c.Query("search"); search != "" {
+		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	// 获取总数
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.workflow.get_failed"),
+		})
+		return
+	}
+
+	var workflows []models.Workflow
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&workflows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.workflow.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     workflows,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetWorkflow 获取单个工作流
+func GetWorkflow(c *gin.Context) {
+	wf, ok := findWorkflow(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, wf)
+}
+
+// CreateWorkflow 创建工作流
+func CreateWorkflow(c *gin.Context) {
+	var req models.WorkflowCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = models.WorkflowModeSequential
+	}
+	if err := workflow.Validate(mode, req.Steps); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.workflow.invalid", err.Error()),
+		})
+		return
+	}
+	// 工作流可以运行引用的脚本，创建者需要在每个脚本上拥有 operator 权限
+	if !requireScriptsRole(c, stepScripts(req.Steps), models.RoleOperator) {
+		return
+	}
+
+	wf := models.Workflow{
+		Name:        req.Name,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Mode:        mode,
+		Steps:       req.Steps,
+	}
+	if err := database.GetDB().Create(&wf).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.workflow.create_failed"),
+		})
+		return
+	}
+	audit.SetChange(c, wf.ID, nil, wf)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "success.workflow.created"),
+		"data":    wf,
+	})
+}
+
+// UpdateWorkflow 更新工作流
+func UpdateWorkflow(c *gin.Context) {
+	var req models.WorkflowUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	wf, ok := findWorkflow(c)
+	if !ok {
+		return
+	}
+	before := *wf
+
+	// 模式和步骤需要一起校验
+	mode, steps := wf.Mode, wf.Steps
+	if req.Mode != "" {
+		mode = req.Mode
+	}
+	if req.Steps != nil {
+		steps = *req.Steps
+	}
+	if req.Mode != "" || req.Steps != nil {
+		if err := workflow.Validate(mode, steps); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.T(c, "error.workflow.invalid", err.Error()),
+			})
+			return
+		}
+	}
+	if !requireScriptsRole(c, stepScripts(steps), models.RoleOperator) {
+		return
+	}
+
+	// 更新字段
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if req.Mode != "" {
+		updates["mode"] = req.Mode
+	}
+	if req.Steps != nil {
+		updates["steps"] = *req.Steps
+	}
+
+	if len(updates) > 0 {
+		if err := database.GetDB().Model(wf).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": i18n.T(c, "error.workflow.update_failed"),
+			})
+			return
+		}
+	}
+	database.GetDB().First(wf, "id = ?", wf.ID)
+	audit.SetChange(c, wf.ID, before, wf)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.workflow.updated"),
+	})
+}
+
+// DeleteWorkflow 删除工作流
+func DeleteWorkflow(c *gin.Context) {
+	wf, ok := findWorkflow(c)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Delete(wf).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.workflow.delete_failed"),
+		})
+		return
+	}
+
+	// 删除工作流运行记录，以及内联步骤的日志、运行记录和编译缓存
+	workflow.Delete(wf.ID)
+	deleteScriptRuns(wf.ID)
+	file.DeleteScriptLog(wf.ID)
+	executor.CleanBuilds(wf.ID)
+	audit.SetChange(c, wf.ID, wf, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "success.workflow.deleted"),
+	})
+}
+
+// ExecuteWorkflow 手动运行工作流，立即返回运行记录，步骤在后台执行
+func ExecuteWorkflow(c *gin.Context) {
+	wf, ok := findWorkflow(c)
+	if !ok {
+		return
+	}
+	if !wf.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.workflow.disabled"),
+		})
+		return
+	}
+	if !requireScriptsRole(c, stepScripts(wf.Steps), models.RoleOperator) {
+		return
+	}
+
+	payload, _ := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookPayload))
+	run, err := workflow.Start(*wf, models.TriggerManual, string(payload))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.workflow.execute_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": i18n.T(c, "success.workflow.started"),
+		"data":    run,
+	})
+}
+
+// GetWorkflowWebhookURL 获取工作流的 webhook URL，拿到地址即可运行工作流，需要在引用的脚本上拥有 operator 权限
+func GetWorkflowWebhookURL(c *gin.Context) {
+	wf, ok := findWorkflow(c)
+	if !ok {
+		return
+	}
+	if !requireScriptsRole(c, stepScripts(wf.Steps), models.RoleOperator) {
+		return
+	}
+
+	domain, err := webhookDomain(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.config.get_failed"),
+		})
+		return
+	}
+
+	signature := generateWebhookSignature(wf.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"webhook_url":   fmt.Sprintf("%s/hw/%s?signature=%s", domain, wf.ID, signature),
+		"signature":     signature,
+		"workflow_id":   wf.ID,
+		"workflow_name": wf.Name,
+	})
+}
+
+// WorkflowWebhookHandler 处理工作流的 webhook 请求
+func WorkflowWebhookHandler(c *gin.Context) {
+	startTime := time.Now()
+	workflowID := c.Param("id")
+
+	// 验证签名
+	if status, errorMsg := checkWebhookSignature(c, workflowID); status != 0 {
+		LogWorkflowWebhookCall(c, workflowID, status, time.Since(startTime).Milliseconds(), errorMsg)
+		c.JSON(status, gin.H{
+			"error": errorMsg,
+		})
+		return
+	}
+
+	var wf models.Workflow
+	if err := database.GetDB().First(&wf, "id = ?", workflowID).Error; err != nil || !wf.Enabled {
+		errorMsg := i18n.T(c, "error.workflow.not_found_or_disabled")
+		LogWorkflowWebhookCall(c, workflowID, http.StatusNotFound, time.Since(startTime).Milliseconds(), errorMsg)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errorMsg,
+		})
+		return
+	}
+
+	// 请求体作为各步骤的标准输入
+	payload, _ := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookPayload))
+	c.Request.Body = io.NopCloser(bytes.NewReader(payload))
+
+	run, err := workflow.Start(wf, models.TriggerWebhook, string(payload))
+	if err != nil {
+		errorMsg := i18n.T(c, "error.workflow.execute_failed")
+		LogWorkflowWebhookCall(c, workflowID, http.StatusInternalServerError, time.Since(startTime).Milliseconds(), err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": errorMsg,
+		})
+		return
+	}
+
+	LogWorkflowWebhookCall(c, workflowID, http.StatusOK, time.Since(startTime).Milliseconds(), "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": i18n.T(c, "success.workflow.started"),
+		"data": gin.H{
+			"workflow_id":   wf.ID,
+			"workflow_name": wf.Name,
+			"run_id":        run.ID,
+			"timestamp":     startTime.Unix(),
+		},
+	})
+}
+
+// GetWorkflowRuns 获取工作流的运行记录
+func GetWorkflowRuns(c *gin.Context) {
+	var req models.ScriptRunListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100 // 限制最大页面大小
+	}
+
+	db := database.GetDB()
+	query := db.Model(&models.WorkflowRun{}).Where("workflow_id = ?", c.Param("id"))
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Trigger != "" {
+		query = query.Where("trigger = ?", req.Trigger)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.run.get_failed"),
+		})
+		return
+	}
+
+	var runs []models.WorkflowRun
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("started_at DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.run.get_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WorkflowRunListResponse{
+		Data:     runs,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
+
+// GetWorkflowWebhookLogs 获取工作流的 webhook 调用记录
+func GetWorkflowWebhookLogs(c *gin.Context) {
+	var req models.WebhookLogListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.T(c, "error.request.invalid_params", err.Error()),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100 // 限制最大页面大小
+	}
+
+	db := database.GetDB()
+	query := db.Model(&models.WorkflowWebhookLog{}).Where("workflow_id = ?", c.Param("id"))
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.webhook.get_logs_failed"),
+		})
+		return
+	}
+
+	var logs []models.WorkflowWebhookLog
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("created_at DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.webhook.get_logs_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WorkflowWebhookLogListResponse{
+		Data:     logs,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
+
+// GetWorkflowRun 获取工作流运行详情（包含每个步骤的状态和输出）
+func GetWorkflowRun(c *gin.Context) {
+	var run models.WorkflowRun
+	if err := database.GetDB().Preload("StepRuns", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("position ASC")
+	}).First(&run, "id = ?", c.Param("runId")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.run.not_found"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// stepScripts 工作流步骤引用的脚本 ID，不含内联步骤
+func stepScripts(steps models.WorkflowSteps) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, step := range steps {
+		if step.ScriptID != "" && !seen[step.ScriptID] {
+			seen[step.ScriptID] = true
+			ids = append(ids, step.ScriptID)
+		}
+	}
+	return ids
+}
+
+// findWorkflow 根据路径参数加载工作流，失败时写入错误响应
+func findWorkflow(c *gin.Context) (*models.Workflow, bool) {
+	var wf models.Workflow
+	if err := database.GetDB().First(&wf, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": i18n.T(c, "error.workflow.not_found"),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.T(c, "error.database.query_failed"),
+		})
+		return nil, false
+	}
+	return &wf, true
+}
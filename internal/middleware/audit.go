@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"hook-panel/internal/pkg/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audit 审计中间件，在修改类请求处理完成后记录审计事件，只读请求不记录
+func Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		// 未匹配到路由的请求不记录
+		if c.FullPath() == "" {
+			return
+		}
+		audit.Record(c)
+	}
+}
@@ -1,20 +1,30 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"hook-panel/internal/pkg/auth"
 	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/lockout"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware 密钥认证中间件
+// DeprecatedKeyHeader 使用宽限期内的旧密钥认证时返回的响应头
+const DeprecatedKeyHeader = "X-Hook-Key-Deprecated"
+
+// enrollmentPaths 尚未绑定两步验证的账号可以访问的接口
+var enrollmentPaths = []string{
+	"/api/auth/me",
+	"/api/auth/logout",
+	"/api/auth/password",
+	"/api/auth/totp/",
+}
+
+// AuthMiddleware 认证中间件，接受访问密钥、API 令牌或登录会话令牌
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取密钥
-		secretKey := auth.GetSecretKey()
-
 		// 从 Header 中获取 Authorization
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -44,16 +54,99 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 验证 token
-		if token != secretKey {
+		// 认证失败次数过多的来源在锁定期内直接拒绝
+		if !lockout.Guard(c) {
+			return
+		}
+
+		// 验证 token：访问密钥拥有全部权限，其余按 API 令牌或登录会话认证。
+		// 轮换宽限期内旧密钥仍然有效，响应头提示调用方尽快更换
+		for i, secretKey := range auth.SecretKeys() {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(secretKey)) == 1 {
+				if i > 0 {
+					c.Header(DeprecatedKeyHeader, "true")
+				}
+				auth.SetIdentity(c, &auth.Identity{})
+				c.Next()
+				return
+			}
+		}
+
+		if auth.IsAPIToken(token) {
+			apiToken, err := auth.LookupAPIToken(token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": i18n.T(c, "error.auth.invalid_token"),
+				})
+				c.Abort()
+				return
+			}
+			auth.SetIdentity(c, &auth.Identity{Token: apiToken})
+			c.Next()
+			return
+		}
+
+		user, session, err := auth.LookupSession(token)
+		if err != nil {
+			// 会话和 API 令牌是 256 位随机值，无法猜测，过期的令牌也不应导致浏览器所在 IP 被锁定，
+			// 只有其他格式（即尝试访问密钥）的失败计入
+			if !auth.IsSessionToken(token) {
+				lockout.Fail(c, lockout.ScopeAPI)
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": i18n.T(c, "error.auth.invalid_token"),
 			})
 			c.Abort()
 			return
 		}
+		auth.SetIdentity(c, &auth.Identity{User: user, Session: session})
+
+		// 系统要求两步验证时，尚未绑定的账号只能访问 /api/auth 下的接口完成绑定
+		if auth.EnrollmentRequired(user, session) && !enrollmentPath(c.FullPath()) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":                    i18n.T(c, "error.auth.totp_enrollment_required"),
+				"totp_enrollment_required": true,
+			})
+			c.Abort()
+			return
+		}
 
 		// 认证通过，继续处理请求
 		c.Next()
 	}
 }
+
+// enrollmentPath 是否为绑定两步验证期间允许访问的接口
+func enrollmentPath(path string) bool {
+	for _, prefix := range enrollmentPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unrestricted 拒绝限定脚本的 API 令牌，用于不针对单个脚本、无法按脚本过滤的路由
+func Unrestricted() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity := auth.CurrentIdentity(c); identity == nil || identity.ScriptScope() != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": i18n.T(c, "error.auth.token_restricted"),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole 要求全局角色至少为 role，访问密钥视为 admin
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity := auth.CurrentIdentity(c); identity == nil || !identity.HasRole(role) {
+			forbidden(c, role)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditEvent 审计事件，记录每次修改类 API 调用
+type AuditEvent struct {
+	ID        string       `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Actor     string       `json:"actor" gorm:"size:100;index"`        // 操作者名称，与版本历史的作者一致
+	ActorType string       `json:"actor_type" gorm:"size:20"`          // key / user / token / anonymous
+	Action    string       `json:"action" gorm:"size:100;index"`       // 如 scripts.update、config.update
+	Method    string       `json:"method" gorm:"size:10"`              // HTTP 方法
+	Path      string       `json:"path" gorm:"size:500"`               // 请求路径
+	Target    string       `json:"target" gorm:"size:255;index"`       // 操作对象，如脚本 ID
+	Status    int          `json:"status"`                             // 响应状态码
+	Changes   AuditChanges `json:"changes,omitempty" gorm:"type:text"` // 修改前后有变化的字段
+	IP        string       `json:"ip" gorm:"size:64"`
+	UserAgent string       `json:"user_agent" gorm:"size:500"`
+	CreatedAt time.Time    `json:"created_at" gorm:"index"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// AuditChange 单个字段修改前后的值，新建时 from 为空，删除时 to 为空
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditChanges 按字段名记录的修改（JSON 格式存储）
+type AuditChanges map[string]AuditChange
+
+// Value 实现 driver.Valuer
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return jsonValue(c)
+}
+
+// Scan 实现 sql.Scanner
+func (c *AuditChanges) Scan(src interface{}) error {
+	return jsonScan(src, c)
+}
+
+// AuditQuery 审计日志筛选条件
+type AuditQuery struct {
+	Actor    string     `form:"actor"`
+	Action   string     `form:"action"` // 前缀匹配，如 scripts 匹配全部脚本操作
+	Target   string     `form:"target"`
+	IP       string     `form:"ip"`
+	From     *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int        `form:"page"`
+	PageSize int        `form:"page_size"`
+}
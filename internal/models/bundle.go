@@ -0,0 +1,15 @@
+package models
+
+// 导入时的冲突处理策略
+const (
+	ImportSkip      = "skip"
+	ImportOverwrite = "overwrite"
+	ImportRename    = "rename"
+)
+
+// BundleImportRequest 导入脚本包请求
+type BundleImportRequest struct {
+	Strategy string `form:"strategy" binding:"omitempty,oneof=skip overwrite rename"` // 与已有脚本冲突时的处理策略，默认跳过
+	RemapIDs bool   `form:"remap_ids"`                                                // 为导入的脚本生成新 ID，默认保留原 ID 以保持 Webhook 地址不变
+	DryRun   bool   `form:"dry_run"`                                                  // 只返回导入计划，不做任何修改
+}
@@ -88,6 +88,16 @@ var DefaultSystemConfigs = []SystemConfig{
 		Required:    false,
 		Encrypted:   false,
 	},
+	{
+		Key:         "webhook.retry_timeout",
+		Value:       "600",
+		Type:        "number",
+		Category:    "system",
+		Label:       "config.webhook_retry_timeout.label",
+		Description: "config.webhook_retry_timeout.description",
+		Required:    false,
+		Encrypted:   false,
+	},
 	{
 		Key:         "system.language",
 		Value:       "zh-CN",
@@ -99,4 +109,300 @@ var DefaultSystemConfigs = []SystemConfig{
 		Required:    false,
 		Encrypted:   false,
 	},
+	{
+		Key:         "artifacts.max_size_mb",
+		Value:       "100",
+		Type:        "number",
+		Category:    "artifacts",
+		Label:       "config.artifacts_max_size.label",
+		Description: "config.artifacts_max_size.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "artifacts.retention_days",
+		Value:       "7",
+		Type:        "number",
+		Category:    "artifacts",
+		Label:       "config.artifacts_retention.label",
+		Description: "config.artifacts_retention.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "toolchain.go",
+		Value:       "go",
+		Type:        "string",
+		Category:    "toolchain",
+		Label:       "config.toolchain_go.label",
+		Description: "config.toolchain_go.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "toolchain.javac",
+		Value:       "javac",
+		Type:        "string",
+		Category:    "toolchain",
+		Label:       "config.toolchain_javac.label",
+		Description: "config.toolchain_javac.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "toolchain.java",
+		Value:       "java",
+		Type:        "string",
+		Category:    "toolchain",
+		Label:       "config.toolchain_java.label",
+		Description: "config.toolchain_java.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "toolchain.rustc",
+		Value:       "",
+		Type:        "string",
+		Category:    "toolchain",
+		Label:       "config.toolchain_rustc.label",
+		Description: "config.toolchain_rustc.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "toolchain.cc",
+		Value:       "",
+		Type:        "string",
+		Category:    "toolchain",
+		Label:       "config.toolchain_cc.label",
+		Description: "config.toolchain_cc.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "storage.mode",
+		Value:       "file",
+		Type:        "select",
+		Category:    "storage",
+		Label:       "config.storage_mode.label",
+		Description: "config.storage_mode.description",
+		Options:     `[{"label":"File","value":"file"},{"label":"Git","value":"git"}]`,
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "storage.git_path",
+		Value:       "./data/repo",
+		Type:        "string",
+		Category:    "storage",
+		Label:       "config.storage_git_path.label",
+		Description: "config.storage_git_path.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "storage.git_remote",
+		Value:       "",
+		Type:        "string",
+		Category:    "storage",
+		Label:       "config.storage_git_remote.label",
+		Description: "config.storage_git_remote.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "storage.git_branch",
+		Value:       "main",
+		Type:        "string",
+		Category:    "storage",
+		Label:       "config.storage_git_branch.label",
+		Description: "config.storage_git_branch.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "auth.session_ttl_hours",
+		Value:       "24",
+		Type:        "number",
+		Category:    "auth",
+		Label:       "config.auth_session_ttl.label",
+		Description: "config.auth_session_ttl.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "auth.require_totp",
+		Value:       "false",
+		Type:        "select",
+		Category:    "auth",
+		Label:       "config.auth_require_totp.label",
+		Description: "config.auth_require_totp.description",
+		Options:     `[{"label":"Off","value":"false"},{"label":"On","value":"true"}]`,
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "auth.key_rotation_grace_hours",
+		Value:       "24",
+		Type:        "number",
+		Category:    "auth",
+		Label:       "config.auth_key_rotation_grace.label",
+		Description: "config.auth_key_rotation_grace.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "auth.lockout_threshold",
+		Value:       "10",
+		Type:        "number",
+		Category:    "auth",
+		Label:       "config.auth_lockout_threshold.label",
+		Description: "config.auth_lockout_threshold.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "auth.lockout_minutes",
+		Value:       "15",
+		Type:        "number",
+		Category:    "auth",
+		Label:       "config.auth_lockout_minutes.label",
+		Description: "config.auth_lockout_minutes.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "auth.trusted_proxies",
+		Value:       "",
+		Type:        "string",
+		Category:    "auth",
+		Label:       "config.auth_trusted_proxies.label",
+		Description: "config.auth_trusted_proxies.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.enabled",
+		Value:       "false",
+		Type:        "select",
+		Category:    "oidc",
+		Label:       "config.oidc_enabled.label",
+		Description: "config.oidc_enabled.description",
+		Options:     `[{"label":"Off","value":"false"},{"label":"On","value":"true"}]`,
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.issuer",
+		Value:       "",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_issuer.label",
+		Description: "config.oidc_issuer.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.client_id",
+		Value:       "",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_client_id.label",
+		Description: "config.oidc_client_id.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.client_secret",
+		Value:       "",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_client_secret.label",
+		Description: "config.oidc_client_secret.description",
+		Required:    false,
+		Encrypted:   true,
+	},
+	{
+		Key:         "oidc.redirect_url",
+		Value:       "",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_redirect_url.label",
+		Description: "config.oidc_redirect_url.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.scopes",
+		Value:       "openid profile email",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_scopes.label",
+		Description: "config.oidc_scopes.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.username_claim",
+		Value:       "preferred_username",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_username_claim.label",
+		Description: "config.oidc_username_claim.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.groups_claim",
+		Value:       "groups",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_groups_claim.label",
+		Description: "config.oidc_groups_claim.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.role_mapping",
+		Value:       "",
+		Type:        "string",
+		Category:    "oidc",
+		Label:       "config.oidc_role_mapping.label",
+		Description: "config.oidc_role_mapping.description",
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.default_role",
+		Value:       "viewer",
+		Type:        "select",
+		Category:    "oidc",
+		Label:       "config.oidc_default_role.label",
+		Description: "config.oidc_default_role.description",
+		Options:     `[{"label":"Reject","value":"none"},{"label":"Viewer","value":"viewer"},{"label":"Operator","value":"operator"},{"label":"Editor","value":"editor"},{"label":"Admin","value":"admin"}]`,
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.auto_provision",
+		Value:       "true",
+		Type:        "select",
+		Category:    "oidc",
+		Label:       "config.oidc_auto_provision.label",
+		Description: "config.oidc_auto_provision.description",
+		Options:     `[{"label":"Off","value":"false"},{"label":"On","value":"true"}]`,
+		Required:    false,
+		Encrypted:   false,
+	},
+	{
+		Key:         "oidc.link_verified_email",
+		Value:       "false",
+		Type:        "select",
+		Category:    "oidc",
+		Label:       "config.oidc_link_verified_email.label",
+		Description: "config.oidc_link_verified_email.description",
+		Options:     `[{"label":"Off","value":"false"},{"label":"On","value":"true"}]`,
+		Required:    false,
+		Encrypted:   false,
+	},
 }
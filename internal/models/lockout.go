@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Lockout 来源 IP 的认证失败记录，达到阈值后在一段时间内拒绝该 IP 的认证请求
+type Lockout struct {
+	IP            string     `json:"ip"`
+	Failures      int        `json:"failures"` // 当前窗口内的失败次数
+	Scope         string     `json:"scope"`    // 失败的认证类型：api / login / totp / webhook，各类型分别计数
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	Locked        bool       `json:"locked"`
+	LockedUntil   *time.Time `json:"locked_until"` // 未锁定时为空
+}
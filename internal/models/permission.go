@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 用户角色，权限依次递增
+const (
+	RoleViewer   = "viewer"   // 只能查看
+	RoleOperator = "operator" // 可以查看和运行
+	RoleEditor   = "editor"   // 可以创建、修改和删除
+	RoleAdmin    = "admin"    // 全部权限，包括用户和系统配置管理
+)
+
+// ScriptPermission 用户在单个脚本或脚本分组上的角色，覆盖用户的全局角色
+type ScriptPermission struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID    string    `json:"user_id" gorm:"not null;type:varchar(36);index"`
+	ScriptID  string    `json:"script_id" gorm:"type:varchar(36);index"` // 与 group 二选一
+	Group     string    `json:"group" gorm:"column:group_name;size:100;index"`
+	Role      string    `json:"role" gorm:"not null;size:20"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (p *ScriptPermission) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ScriptPermission) TableName() string {
+	return "script_permissions"
+}
+
+// PermissionGrant 单条授权，script_id 与 group 必须且只能设置一个
+type PermissionGrant struct {
+	ScriptID string `json:"script_id"`
+	Group    string `json:"group" binding:"max=100"`
+	Role     string `json:"role" binding:"required,oneof=editor operator viewer"`
+}
+
+// PermissionsUpdateRequest 替换用户的全部授权
+type PermissionsUpdateRequest struct {
+	Permissions []PermissionGrant `json:"permissions" binding:"dive"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 运行触发来源
+const (
+	TriggerManual   = "manual"
+	TriggerWebhook  = "webhook"
+	TriggerSchedule = "schedule"
+	TriggerDelayed  = "delayed"
+	TriggerChain    = "chain"
+	TriggerWorkflow = "workflow"
+	TriggerWatch    = "watch"
+)
+
+// 运行状态
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusFailed  = "failed"
+)
+
+// ScriptRun 脚本运行记录，一次逻辑运行可能包含多次重试
+type ScriptRun struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ScriptID      string     `json:"script_id" gorm:"not null;type:varchar(36);index"`
+	Trigger       string     `json:"trigger" gorm:"not null;size:20"`
+	ParentRunID   string     `json:"parent_run_id" gorm:"type:varchar(36);index"` // 链式触发时的上一个运行
+	ScriptVersion int        `json:"script_version"`                              // 执行的脚本版本，内联内容为 0
+	Status        string     `json:"status" gorm:"not null;size:20;index"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	ExitCode      int        `json:"exit_code" gorm:"default:0"`
+	Duration      int64      `json:"duration" gorm:"comment:Duration in milliseconds"`
+	ArtifactCount int        `json:"artifact_count"`
+	ArtifactSize  int64      `json:"artifact_size" gorm:"comment:Total artifact size in bytes"`
+	StartedAt     time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// 关联的尝试记录
+	AttemptList []ScriptRunAttempt `json:"attempt_list,omitempty" gorm:"foreignKey:RunID;references:ID"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (r *ScriptRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ScriptRun) TableName() string {
+	return "script_runs"
+}
+
+// ScriptRunAttempt 单次执行尝试
+type ScriptRunAttempt struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	RunID      string    `json:"run_id" gorm:"not null;type:varchar(36);index"`
+	Attempt    int       `json:"attempt" gorm:"not null"`
+	Success    bool      `json:"success"`
+	ExitCode   int       `json:"exit_code"`
+	TimedOut   bool      `json:"timed_out"`
+	Phase      string    `json:"phase" gorm:"size:20"`
+	Output     string    `json:"output" gorm:"type:text"`
+	Error      string    `json:"error" gorm:"type:text"`
+	Duration   int64     `json:"duration" gorm:"comment:Duration in milliseconds"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (a *ScriptRunAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ScriptRunAttempt) TableName() string {
+	return "script_run_attempts"
+}
+
+// ScriptRunListRequest 运行记录查询请求
+type ScriptRunListRequest struct {
+	Status   string `form:"status"`
+	Trigger  string `form:"trigger"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// ScriptRunListResponse 运行记录查询响应
+type ScriptRunListResponse struct {
+	Data     []ScriptRun `json:"data"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 计划运行状态
+const (
+	ScheduledRunPending   = "pending"
+	ScheduledRunRunning   = "running"
+	ScheduledRunDone      = "done"
+	ScheduledRunFailed    = "failed"
+	ScheduledRunCancelled = "cancelled"
+)
+
+// ScheduledRun 一次性的延时/定点运行
+type ScheduledRun struct {
+	ID         string      `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ScriptID   string      `json:"script_id" gorm:"not null;type:varchar(36);index"`
+	RunAt      time.Time   `json:"run_at" gorm:"not null;index"`
+	Params     ParamValues `json:"params" gorm:"type:text"`
+	Stdin      string      `json:"stdin" gorm:"type:text"`
+	Status     string      `json:"status" gorm:"not null;size:20;index"`
+	RunID      string      `json:"run_id" gorm:"type:varchar(36)"` // 执行后关联的运行记录
+	Error      string      `json:"error" gorm:"type:text"`
+	FinishedAt *time.Time  `json:"finished_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (r *ScheduledRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ScheduledRun) TableName() string {
+	return "scheduled_runs"
+}
+
+// ScheduledRunCreateRequest 创建计划运行请求，run_at 与 delay 二选一
+type ScheduledRunCreateRequest struct {
+	RunAt  *time.Time             `json:"run_at"` // 执行时间（RFC 3339）
+	Delay  int                    `json:"delay"`  // 延迟秒数
+	Params map[string]interface{} `json:"params"`
+	Stdin  string                 `json:"stdin"`
+}
+
+// ScheduledRunListRequest 计划运行查询请求
+type ScheduledRunListRequest struct {
+	Status   string `form:"status"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// ScheduledRunListResponse 计划运行查询响应
+type ScheduledRunListResponse struct {
+	Data     []ScheduledRun `json:"data"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
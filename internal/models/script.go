@@ -1,6 +1,8 @@
 package models
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,15 +11,24 @@ import (
 
 // Script 脚本模型
 type Script struct {
-	ID          string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	Name        string     `json:"name" gorm:"not null;size:255" binding:"required"`
-	Description string     `json:"description" gorm:"size:1000"`
-	Executor    string     `json:"executor" gorm:"not null;size:20;default:bash" binding:"required"`
-	Enabled     bool       `json:"enabled" gorm:"default:true"`
-	CallCount   int64      `json:"call_count" gorm:"default:0"`
-	LastCallAt  *time.Time `json:"last_call_at"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          string           `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name        string           `json:"name" gorm:"not null;size:255" binding:"required"`
+	Description string           `json:"description" gorm:"size:1000"`
+	Group       string           `json:"group" gorm:"column:group_name;size:100;index"` // 分组，用于按组授权
+	Executor    string           `json:"executor" gorm:"not null;size:20;default:bash" binding:"required"`
+	Enabled     bool             `json:"enabled" gorm:"default:true"`
+	Parameters  ScriptParameters `json:"parameters" gorm:"type:text"`
+	Env         StringMap        `json:"env" gorm:"type:text"` // 执行时附加的环境变量
+	Retry       RetryPolicy      `json:"retry" gorm:"embedded;embeddedPrefix:retry_"`
+	Schedule    CronSchedule     `json:"schedule" gorm:"embedded;embeddedPrefix:schedule_"`
+	Chain       ScriptChain      `json:"chain" gorm:"embedded;embeddedPrefix:chain_"`
+	Watch       FileWatch        `json:"watch" gorm:"embedded;embeddedPrefix:watch_"`
+	Managed     bool             `json:"managed"`              // 由声明式配置文件管理，API 中只读
+	NextRunAt   *time.Time       `json:"next_run_at" gorm:"-"` // 下次定时触发时间，由调度器计算
+	CallCount   int64            `json:"call_count" gorm:"default:0"`
+	LastCallAt  *time.Time       `json:"last_call_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
 }
 
 // BeforeCreate GORM 钩子，在创建前生成 UUID
@@ -33,22 +44,166 @@ func (Script) TableName() string {
 	return "scripts"
 }
 
+// 重试退避方式
+const (
+	BackoffFixed       = "fixed"
+	BackoffExponential = "exponential"
+)
+
+// RetryPolicy 失败重试策略
+type RetryPolicy struct {
+	MaxAttempts int     `json:"max_attempts" gorm:"default:1"`        // 最大尝试次数（含首次执行）
+	Backoff     string  `json:"backoff" gorm:"size:20;default:fixed"` // 退避方式：fixed / exponential
+	Delay       int     `json:"delay"`                                // 首次重试前的等待时间（秒）
+	MaxDelay    int     `json:"max_delay"`                            // 指数退避的等待上限（秒），为 0 时使用 300
+	ExitCodes   IntList `json:"exit_codes" gorm:"type:text"`          // 仅在这些退出码时重试，为空表示任意失败都重试
+	OnTimeout   bool    `json:"on_timeout"`                           // 执行超时时是否重试
+}
+
+// DefaultRetryPolicy 默认不重试
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 1,
+		Backoff:     BackoffFixed,
+		Delay:       10,
+		MaxDelay:    300,
+	}
+}
+
+// Validate 校验重试策略
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 || p.MaxAttempts > 10 {
+		return fmt.Errorf("max_attempts must be between 1 and 10")
+	}
+	if p.Backoff != BackoffFixed && p.Backoff != BackoffExponential {
+		return fmt.Errorf("backoff must be %s or %s", BackoffFixed, BackoffExponential)
+	}
+	if p.Delay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("delay must not be negative")
+	}
+	return nil
+}
+
+// 错过触发的处理策略
+const (
+	MissedRunSkip    = "skip"
+	MissedRunRunOnce = "run_once"
+)
+
+// CronSchedule 定时触发配置
+type CronSchedule struct {
+	Cron        string     `json:"cron" gorm:"size:100"`                   // cron 表达式，支持可选的秒字段，为空表示不定时触发
+	Timezone    string     `json:"timezone" gorm:"size:64"`                // 时区（IANA 名称），为空使用服务器时区
+	MissedRun   string     `json:"missed_run" gorm:"size:20;default:skip"` // 停机期间错过触发的处理策略：skip / run_once
+	LastFiredAt *time.Time `json:"last_fired_at"`                          // 最近一次触发（或开始调度）的时间，用于判断是否错过触发
+}
+
+// 脚本参数类型
+const (
+	ParameterTypeString = "string"
+	ParameterTypeNumber = "number"
+	ParameterTypeBool   = "bool"
+	ParameterTypeChoice = "choice"
+)
+
+// FileWatch 文件监听触发配置
+type FileWatch struct {
+	Path      string `json:"path" gorm:"size:1000"`   // 监听的目录，为空表示不监听
+	Pattern   string `json:"pattern" gorm:"size:255"` // 文件名匹配的 glob，含 / 时匹配相对路径，为空匹配全部文件
+	Recursive bool   `json:"recursive"`               // 是否监听子目录
+	Debounce  int    `json:"debounce"`                // 防抖时间（毫秒），最后一次变更后等待该时间再触发，为 0 时使用 1000
+}
+
+// ScriptChain 运行结束后触发的后续脚本
+type ScriptChain struct {
+	OnSuccess StringList `json:"on_success" gorm:"type:text"` // 成功后触发的脚本 ID
+	OnFailure StringList `json:"on_failure" gorm:"type:text"` // 失败后触发的脚本 ID
+	Always    StringList `json:"always" gorm:"type:text"`     // 无论成败都触发的脚本 ID
+}
+
+// Next 根据运行结果返回需要触发的后续脚本
+func (c ScriptChain) Next(success bool) []string {
+	next := c.OnFailure
+	if success {
+		next = c.OnSuccess
+	}
+	return append(append([]string{}, next...), c.Always...)
+}
+
+// Targets 返回全部后续脚本
+func (c ScriptChain) Targets() []string {
+	var ids []string
+	for _, list := range []StringList{c.OnSuccess, c.OnFailure, c.Always} {
+		ids = append(ids, list...)
+	}
+	return ids
+}
+
+// ScriptParameter 脚本参数定义，执行时以 HOOK_PARAM_<NAME> 环境变量传入
+type ScriptParameter struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default"`
+	Required    bool     `json:"required"`
+	Options     []string `json:"options,omitempty"` // choice 类型的可选值
+}
+
+// ScriptParameters 脚本参数列表（JSON 格式存储）
+type ScriptParameters []ScriptParameter
+
+// Value 实现 driver.Valuer
+func (p ScriptParameters) Value() (driver.Value, error) {
+	if p == nil {
+		p = ScriptParameters{}
+	}
+	return jsonValue(p)
+}
+
+// Scan 实现 sql.Scanner
+func (p *ScriptParameters) Scan(src interface{}) error {
+	return jsonScan(src, p)
+}
+
 // ScriptCreateRequest 创建脚本请求
 type ScriptCreateRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	Content     string `json:"content"`
-	Executor    string `json:"executor" binding:"required,oneof=bash sh python python3 node php ruby perl go java powershell cmd"`
-	Enabled     bool   `json:"enabled"`
+	Name        string           `json:"name" binding:"required"`
+	Description string           `json:"description"`
+	Group       string           `json:"group" binding:"max=100"`
+	Content     string           `json:"content"`
+	Executor    string           `json:"executor" binding:"required,oneof=bash sh python python3 node php ruby perl go java powershell cmd auto rust c"`
+	Enabled     bool             `json:"enabled"`
+	Parameters  ScriptParameters `json:"parameters"`
+	Env         StringMap        `json:"env"`
+	Retry       *RetryPolicy     `json:"retry"`
+	Schedule    *CronSchedule    `json:"schedule"`
+	Chain       *ScriptChain     `json:"chain"`
+	Watch       *FileWatch       `json:"watch"`
+	Message     string           `json:"message"` // 版本说明
 }
 
 // ScriptUpdateRequest 更新脚本请求
 type ScriptUpdateRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Content     string `json:"content"`
-	Executor    string `json:"executor" binding:"omitempty,oneof=bash sh python python3 node php ruby perl go java powershell cmd"`
-	Enabled     *bool  `json:"enabled"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Group       *string           `json:"group" binding:"omitempty,max=100"`
+	Content     string            `json:"content"`
+	Executor    string            `json:"executor" binding:"omitempty,oneof=bash sh python python3 node php ruby perl go java powershell cmd auto rust c"`
+	Enabled     *bool             `json:"enabled"`
+	Parameters  *ScriptParameters `json:"parameters"`
+	Env         *StringMap        `json:"env"`
+	Retry       *RetryPolicy      `json:"retry"`
+	Schedule    *CronSchedule     `json:"schedule"`
+	Chain       *ScriptChain      `json:"chain"`
+	Watch       *FileWatch        `json:"watch"`
+	Message     string            `json:"message"` // 版本说明，内容或执行器变更时记录到新版本
+}
+
+// ScriptExecuteRequest 手动执行脚本请求
+type ScriptExecuteRequest struct {
+	Params map[string]interface{} `json:"params"` // 参数值，按脚本声明的参数校验
+	Stdin  string                 `json:"stdin"`  // 写入脚本标准输入的文本
 }
 
 // ScriptResponse 脚本响应（包含内容）
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScriptTemplate 脚本模板，内容使用 Go text/template 语法引用参数，如 {{quote .service}}
+type ScriptTemplate struct {
+	ID          string           `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name        string           `json:"name" gorm:"not null;size:255"`
+	Description string           `json:"description" gorm:"size:1000"`
+	Executor    string           `json:"executor" gorm:"not null;size:20"`
+	Content     string           `json:"content" gorm:"type:text"`
+	Parameters  ScriptParameters `json:"parameters" gorm:"type:text"` // 渲染模板时需要填写的参数
+	BuiltIn     bool             `json:"built_in" gorm:"-"`           // 内置模板，不能修改和删除
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (t *ScriptTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ScriptTemplate) TableName() string {
+	return "script_templates"
+}
+
+// TemplateCreateRequest 保存模板请求
+type TemplateCreateRequest struct {
+	Name        string           `json:"name" binding:"required"`
+	Description string           `json:"description"`
+	Executor    string           `json:"executor" binding:"required,oneof=bash sh python python3 node php ruby perl go java powershell cmd auto rust c"`
+	Content     string           `json:"content" binding:"required"`
+	Parameters  ScriptParameters `json:"parameters"`
+}
+
+// TemplateUpdateRequest 更新模板请求
+type TemplateUpdateRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Executor    string            `json:"executor" binding:"omitempty,oneof=bash sh python python3 node php ruby perl go java powershell cmd auto rust c"`
+	Content     string            `json:"content"`
+	Parameters  *ScriptParameters `json:"parameters"`
+}
+
+// ScriptFromTemplateRequest 从模板创建脚本请求
+type ScriptFromTemplateRequest struct {
+	TemplateID  string                 `json:"template_id" binding:"required"`
+	Params      map[string]interface{} `json:"params"` // 模板参数值，按模板声明的参数校验
+	Name        string                 `json:"name"`   // 脚本名称，为空时使用模板名称
+	Description string                 `json:"description"`
+	Enabled     bool                   `json:"enabled"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// API 令牌权限范围
+const (
+	ScopeRead    = "read"    // 查看，相当于 viewer
+	ScopeExecute = "execute" // 运行，相当于 operator
+	ScopeManage  = "manage"  // 创建、修改和删除，相当于 editor
+)
+
+// APIToken 供自动化调用的 API 令牌，只保存令牌的哈希
+type APIToken struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name       string     `json:"name" gorm:"not null;size:100"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null;size:64"` // 令牌的 SHA-256
+	Prefix     string     `json:"prefix" gorm:"size:20"`                 // 令牌开头几位，便于识别
+	Scopes     StringList `json:"scopes" gorm:"type:text"`
+	ScriptIDs  StringList `json:"script_ids" gorm:"type:text"` // 为空表示不限脚本，否则只能访问这些脚本
+	ExpiresAt  *time.Time `json:"expires_at"`                  // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedBy  string     `json:"created_by" gorm:"size:100"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (t *APIToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// HasScope 是否拥有指定权限范围
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Restricted 是否只能访问指定脚本
+func (t *APIToken) Restricted() bool {
+	return len(t.ScriptIDs) > 0
+}
+
+// AllowsScript 是否允许访问指定脚本
+func (t *APIToken) AllowsScript(scriptID string) bool {
+	if !t.Restricted() {
+		return true
+	}
+	for _, id := range t.ScriptIDs {
+		if id == scriptID {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenCreateRequest 创建 API 令牌请求
+type TokenCreateRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=read execute manage"`
+	ScriptIDs []string   `json:"script_ids"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// TokenUpdateRequest 更新 API 令牌请求，令牌本身不能修改
+type TokenUpdateRequest struct {
+	Name      string     `json:"name" binding:"max=100"`
+	Scopes    []string   `json:"scopes" binding:"omitempty,min=1,dive,oneof=read execute manage"`
+	ScriptIDs *[]string  `json:"script_ids"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	NoExpiry  bool       `json:"no_expiry"` // 取消过期时间
+}
+
+// TokenCreateResponse 创建 API 令牌响应，明文令牌只返回这一次
+type TokenCreateResponse struct {
+	APIToken
+	Token string `json:"token"`
+}
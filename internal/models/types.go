@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonValue 将结构化字段序列化为 JSON 文本存储
+func jsonValue(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// jsonScan 从 JSON 文本反序列化结构化字段
+func jsonScan(src interface{}, v interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		return nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("unsupported type %T for JSON column", src)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// IntList 整数列表（JSON 格式存储）
+type IntList []int
+
+// Value 实现 driver.Valuer
+func (l IntList) Value() (driver.Value, error) {
+	if l == nil {
+		l = IntList{}
+	}
+	return jsonValue(l)
+}
+
+// Scan 实现 sql.Scanner
+func (l *IntList) Scan(src interface{}) error {
+	return jsonScan(src, l)
+}
+
+// Contains 判断列表是否包含指定值
+func (l IntList) Contains(v int) bool {
+	for _, item := range l {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamValues 参数值（JSON 格式存储）
+type ParamValues map[string]interface{}
+
+// Value 实现 driver.Valuer
+func (v ParamValues) Value() (driver.Value, error) {
+	if v == nil {
+		v = ParamValues{}
+	}
+	return jsonValue(v)
+}
+
+// Scan 实现 sql.Scanner
+func (v *ParamValues) Scan(src interface{}) error {
+	return jsonScan(src, v)
+}
+
+// StringList 字符串列表（JSON 格式存储）
+type StringList []string
+
+// Value 实现 driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	return jsonValue(l)
+}
+
+// Scan 实现 sql.Scanner
+func (l *StringList) Scan(src interface{}) error {
+	return jsonScan(src, l)
+}
+
+// StringMap 字符串键值对（JSON 格式存储）
+type StringMap map[string]string
+
+// Value 实现 driver.Valuer
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		m = StringMap{}
+	}
+	return jsonValue(m)
+}
+
+// Scan 实现 sql.Scanner
+func (m *StringMap) Scan(src interface{}) error {
+	return jsonScan(src, m)
+}
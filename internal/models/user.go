@@ -0,0 +1,151 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// User 登录用户
+type User struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Username      string     `json:"username" gorm:"uniqueIndex;not null;size:100"`
+	DisplayName   string     `json:"display_name" gorm:"size:255"`
+	PasswordHash  string     `json:"-" gorm:"not null;size:255"` // bcrypt 哈希
+	Role          string     `json:"role" gorm:"not null;size:20;default:viewer"`
+	Disabled      bool       `json:"disabled"`
+	TOTPEnabled   bool       `json:"totp_enabled" gorm:"column:totp_enabled"`
+	TOTPSecret    string     `json:"-" gorm:"column:totp_secret;size:128"` // 加密保存的 Base32 密钥，绑定确认前为待确认状态
+	TOTPLastStep  int64      `json:"-" gorm:"column:totp_last_step"`       // 最近使用的时间步，防止验证码重放
+	RecoveryCodes StringList `json:"-" gorm:"type:text"`                   // 恢复码的 SHA-256
+	OIDCIssuer    string     `json:"oidc_issuer,omitempty" gorm:"column:oidc_issuer;size:255;index:idx_users_oidc"`
+	OIDCSubject   string     `json:"oidc_subject,omitempty" gorm:"column:oidc_subject;size:255;index:idx_users_oidc"` // 单点登录账号的 sub，本地账号为空
+	LastLoginAt   *time.Time `json:"last_login_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (User) TableName() string {
+	return "users"
+}
+
+// 会话的登录方式
+const (
+	SessionPassword = "password" // 用户名密码登录
+	SessionOIDC     = "oidc"     // 单点登录，多因素认证由身份提供方负责
+)
+
+// Session 登录会话，只保存令牌的哈希
+type Session struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID    string    `json:"user_id" gorm:"not null;type:varchar(36);index"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null;size:64"` // 令牌的 SHA-256
+	Method    string    `json:"method" gorm:"not null;size:20;default:password"`
+	IP        string    `json:"ip" gorm:"size:64"`
+	UserAgent string    `json:"user_agent" gorm:"size:500"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// LoginRequest 登录请求，已启用两步验证的账号需要提供验证码或恢复码
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"`
+}
+
+// LoginResponse 登录响应
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+	// TOTPEnrollmentRequired 系统要求两步验证但账号尚未绑定，绑定前只能访问 /api/auth 下的接口
+	TOTPEnrollmentRequired bool `json:"totp_enrollment_required"`
+}
+
+// TOTPSetupResponse 开始绑定两步验证的响应
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth 链接，用于生成二维码
+}
+
+// TOTPCodeRequest 提交验证码的请求
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPDisableRequest 关闭两步验证请求，需要密码和验证码（或恢复码）
+type TOTPDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// KeyRotationRequest 轮换访问密钥请求，grace_hours 为空时使用系统配置的宽限期
+type KeyRotationRequest struct {
+	GraceHours *int `json:"grace_hours" binding:"omitempty,min=0,max=720"`
+}
+
+// KeyRotationResponse 轮换访问密钥响应，新密钥只显示这一次
+type KeyRotationResponse struct {
+	Key                  string             `json:"key"`
+	PreviousKeyExpiresAt time.Time          `json:"previous_key_expires_at"` // 旧密钥和旧签名的失效时间
+	Webhooks             []WebhookURLUpdate `json:"webhooks"`                // 需要更新的 webhook 地址
+}
+
+// WebhookURLUpdate 密钥轮换后需要更新的 webhook 地址
+type WebhookURLUpdate struct {
+	Type       string `json:"type"` // script / workflow
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	WebhookURL string `json:"webhook_url"` // 使用新密钥签名的地址
+}
+
+// PasswordChangeRequest 修改密码请求
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// UserCreateRequest 创建用户请求，设置 oidc_subject 时关联单点登录身份，此时可以不设密码
+type UserCreateRequest struct {
+	Username    string `json:"username" binding:"required,max=100"`
+	DisplayName string `json:"display_name"`
+	Password    string `json:"password" binding:"required_without=OIDCSubject"`
+	Role        string `json:"role" binding:"omitempty,oneof=admin editor operator viewer"` // 为空时为 viewer
+	OIDCSubject string `json:"oidc_subject" binding:"max=255"`
+}
+
+// UserUpdateRequest 更新用户请求，设置 password 时重置密码并注销该用户的全部会话
+type UserUpdateRequest struct {
+	DisplayName string `json:"display_name"`
+	Password    string `json:"password"`
+	Role        string `json:"role" binding:"omitempty,oneof=admin editor operator viewer"`
+	Disabled    *bool  `json:"disabled"`
+	ResetTOTP   bool   `json:"reset_totp"` // 关闭该用户的两步验证，用于丢失设备和恢复码的情况
+	// OIDCSubject 关联的单点登录身份（身份提供方的 sub），空字符串表示取消关联
+	OIDCSubject *string `json:"oidc_subject" binding:"omitempty,max=255"`
+}
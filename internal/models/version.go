@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScriptVersion 脚本内容的历史版本，每次保存生成一个新版本
+type ScriptVersion struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ScriptID  string    `json:"script_id" gorm:"not null;type:varchar(36);uniqueIndex:idx_script_version"`
+	Version   int       `json:"version" gorm:"not null;uniqueIndex:idx_script_version"`
+	Content   string    `json:"content,omitempty" gorm:"type:text"`
+	Executor  string    `json:"executor" gorm:"not null;size:20"`
+	Author    string    `json:"author" gorm:"size:255"`
+	Message   string    `json:"message" gorm:"size:1000"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (v *ScriptVersion) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ScriptVersion) TableName() string {
+	return "script_versions"
+}
+
+// ScriptVersionListResponse 版本列表响应
+type ScriptVersionListResponse struct {
+	Data     []ScriptVersion `json:"data"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// ScriptVersionRestoreRequest 恢复版本请求
+type ScriptVersionRestoreRequest struct {
+	Message string `json:"message"`
+}
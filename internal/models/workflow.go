@@ -0,0 +1,205 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 工作流步骤编排方式
+const (
+	WorkflowModeSequential = "sequential" // 按顺序依次执行，每一步依赖上一步
+	WorkflowModeDAG        = "dag"        // 按 depends_on 声明的依赖关系执行，无依赖的步骤并行执行
+)
+
+// 步骤执行条件，根据所依赖步骤的结果判断
+const (
+	StepConditionSuccess = "success" // 依赖的步骤全部成功
+	StepConditionFailure = "failure" // 依赖的步骤中至少一个失败
+	StepConditionAlways  = "always"  // 依赖的步骤结束后总是执行
+)
+
+// 步骤运行状态
+const (
+	StepStatusPending = "pending"
+	StepStatusRunning = "running"
+	StepStatusSuccess = "success"
+	StepStatusFailed  = "failed"
+	StepStatusSkipped = "skipped"
+)
+
+// Workflow 由多个步骤组成的工作流
+type Workflow struct {
+	ID          string        `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name        string        `json:"name" gorm:"not null;size:255"`
+	Description string        `json:"description" gorm:"size:1000"`
+	Enabled     bool          `json:"enabled" gorm:"default:true"`
+	Mode        string        `json:"mode" gorm:"size:20;default:sequential"`
+	Steps       WorkflowSteps `json:"steps" gorm:"type:text"`
+	CallCount   int64         `json:"call_count" gorm:"default:0"`
+	LastCallAt  *time.Time    `json:"last_call_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (w *Workflow) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (Workflow) TableName() string {
+	return "workflows"
+}
+
+// WorkflowStep 工作流步骤，引用已有脚本或直接提供内联内容
+type WorkflowStep struct {
+	ID        string                 `json:"id"`                   // 步骤标识，在工作流内唯一
+	Name      string                 `json:"name"`                 // 显示名称
+	ScriptID  string                 `json:"script_id,omitempty"`  // 引用的脚本
+	Content   string                 `json:"content,omitempty"`    // 内联脚本内容
+	Executor  string                 `json:"executor,omitempty"`   // 内联脚本的执行器
+	Params    map[string]interface{} `json:"params,omitempty"`     // 引用脚本时传入的参数
+	DependsOn []string               `json:"depends_on,omitempty"` // 依赖的步骤（仅 dag 模式）
+	Condition string                 `json:"condition,omitempty"`  // 执行条件：success / failure / always，默认 success
+}
+
+// WorkflowSteps 工作流步骤列表（JSON 格式存储）
+type WorkflowSteps []WorkflowStep
+
+// Value 实现 driver.Valuer
+func (s WorkflowSteps) Value() (driver.Value, error) {
+	if s == nil {
+		s = WorkflowSteps{}
+	}
+	return jsonValue(s)
+}
+
+// Scan 实现 sql.Scanner
+func (s *WorkflowSteps) Scan(src interface{}) error {
+	return jsonScan(src, s)
+}
+
+// WorkflowRun 工作流运行记录
+type WorkflowRun struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	WorkflowID string     `json:"workflow_id" gorm:"not null;type:varchar(36);index"`
+	Trigger    string     `json:"trigger" gorm:"not null;size:20"`
+	Status     string     `json:"status" gorm:"not null;size:20;index"`
+	Duration   int64      `json:"duration" gorm:"comment:Duration in milliseconds"`
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// 关联的步骤运行记录
+	StepRuns []WorkflowStepRun `json:"step_runs,omitempty" gorm:"foreignKey:WorkflowRunID;references:ID"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (r *WorkflowRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (WorkflowRun) TableName() string {
+	return "workflow_runs"
+}
+
+// WorkflowStepRun 工作流中单个步骤的运行记录
+type WorkflowStepRun struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	WorkflowRunID string     `json:"workflow_run_id" gorm:"not null;type:varchar(36);index"`
+	StepID        string     `json:"step_id" gorm:"not null;size:100"`
+	Name          string     `json:"name" gorm:"size:255"`
+	Position      int        `json:"position"`                       // 步骤在工作流中的顺序
+	Status        string     `json:"status" gorm:"not null;size:20"` // pending / running / success / failed / skipped
+	RunID         string     `json:"run_id" gorm:"type:varchar(36)"` // 对应的脚本运行记录，包含完整输出
+	ExitCode      int        `json:"exit_code"`
+	Output        string     `json:"output" gorm:"type:text"`
+	Error         string     `json:"error" gorm:"type:text"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (r *WorkflowStepRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (WorkflowStepRun) TableName() string {
+	return "workflow_step_runs"
+}
+
+// WorkflowWebhookLog 工作流 webhook 调用记录，与脚本的 webhook_logs 分开保存
+type WorkflowWebhookLog struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	WorkflowID   string    `json:"workflow_id" gorm:"not null;type:varchar(36);index"`
+	Method       string    `json:"method" gorm:"not null;size:10"`
+	Headers      string    `json:"headers" gorm:"type:text"`
+	Body         string    `json:"body" gorm:"type:text"`
+	SourceIP     string    `json:"source_ip" gorm:"size:45"`
+	UserAgent    string    `json:"user_agent" gorm:"size:500"`
+	Status       int       `json:"status" gorm:"not null"`
+	ResponseTime int64     `json:"response_time" gorm:"comment:Response time in milliseconds"`
+	ErrorMsg     string    `json:"error_msg" gorm:"size:1000"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+// BeforeCreate GORM 钩子，在创建前生成 UUID
+func (w *WorkflowWebhookLog) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (WorkflowWebhookLog) TableName() string {
+	return "workflow_webhook_logs"
+}
+
+// WorkflowCreateRequest 创建工作流请求
+type WorkflowCreateRequest struct {
+	Name        string        `json:"name" binding:"required"`
+	Description string        `json:"description"`
+	Enabled     bool          `json:"enabled"`
+	Mode        string        `json:"mode" binding:"omitempty,oneof=sequential dag"`
+	Steps       WorkflowSteps `json:"steps" binding:"required"`
+}
+
+// WorkflowUpdateRequest 更新工作流请求
+type WorkflowUpdateRequest struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Enabled     *bool          `json:"enabled"`
+	Mode        string         `json:"mode" binding:"omitempty,oneof=sequential dag"`
+	Steps       *WorkflowSteps `json:"steps"`
+}
+
+// WorkflowRunListResponse 工作流运行记录查询响应
+type WorkflowRunListResponse struct {
+	Data     []WorkflowRun `json:"data"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// WorkflowWebhookLogListResponse 工作流 webhook 调用记录查询响应
+type WorkflowWebhookLogListResponse struct {
+	Data     []WorkflowWebhookLog `json:"data"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
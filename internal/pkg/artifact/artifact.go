@@ -0,0 +1,272 @@
+package artifact
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"hook-panel/internal/pkg/sysconfig"
+)
+
+const (
+	// Dir 运行产物保存目录
+	Dir = "./data/artifacts"
+	// stagingDir 运行期间脚本写入产物的临时目录
+	stagingDir = "./data/temp/artifacts"
+	// cleanupInterval 过期产物清理间隔
+	cleanupInterval = time.Hour
+)
+
+// ErrInvalidPath 产物路径非法（例如试图访问运行目录之外的文件）
+var ErrInvalidPath = errors.New("invalid artifact path")
+
+// Artifact 产物文件信息
+type Artifact struct {
+	Path    string    `json:"path"` // 相对于运行产物目录的路径
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Prepare 为运行创建临时产物目录，返回绝对路径供脚本写入
+func Prepare(runID string) (string, error) {
+	dir, err := filepath.Abs(filepath.Join(stagingDir, runID))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve artifacts directory: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create artifacts directory: %v", err)
+	}
+	return dir, nil
+}
+
+// Collect 将临时目录中的产物转存到 data/artifacts/<runID>/，超出大小上限的文件会被跳过
+func Collect(runID, staging string) ([]Artifact, []string, error) {
+	defer os.RemoveAll(staging)
+
+	maxBytes := int64(sysconfig.GetInt("artifacts.max_size_mb", 100)) * 1024 * 1024
+	target := filepath.Join(Dir, runID)
+
+	var collected []Artifact
+	var skipped []string
+	var total int64
+
+	err := filepath.WalkDir(staging, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// 只收集普通文件，忽略符号链接等特殊文件
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staging, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if total+info.Size() > maxBytes {
+			skipped = append(skipped, rel)
+			return nil
+		}
+
+		dest := filepath.Join(target, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := moveFile(path, dest); err != nil {
+			return err
+		}
+
+		total += info.Size()
+		collected = append(collected, Artifact{Path: rel, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return collected, skipped, fmt.Errorf("failed to collect artifacts: %v", err)
+	}
+
+	return collected, skipped, nil
+}
+
+// List 列出运行的全部产物
+func List(runID string) ([]Artifact, error) {
+	root := filepath.Join(Dir, runID)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return []Artifact{}, nil
+	}
+
+	artifacts := []Artifact{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, Artifact{Path: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %v", err)
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Path < artifacts[j].Path })
+	return artifacts, nil
+}
+
+// Path 获取产物文件的本地路径，并防止路径穿越
+func Path(runID, rel string) (string, error) {
+	if rel == "" || filepath.IsAbs(rel) {
+		return "", ErrInvalidPath
+	}
+
+	root, err := filepath.Abs(filepath.Join(Dir, runID))
+	if err != nil {
+		return "", err
+	}
+	full := filepath.Join(root, filepath.FromSlash(rel))
+	if !strings.HasPrefix(full, root+string(filepath.Separator)) {
+		return "", ErrInvalidPath
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", ErrInvalidPath
+	}
+	return full, nil
+}
+
+// WriteZip 将运行的全部产物打包写入 w
+func WriteZip(runID string, w io.Writer) error {
+	artifacts, err := List(runID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, a := range artifacts {
+		if err := addToZip(zw, filepath.Join(Dir, runID, filepath.FromSlash(a.Path)), a); err != nil {
+			zw.Close()
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Delete 删除运行的全部产物
+func Delete(runID string) error {
+	if err := os.RemoveAll(filepath.Join(Dir, runID)); err != nil {
+		return fmt.Errorf("failed to delete artifacts: %v", err)
+	}
+	return nil
+}
+
+// Cleanup 删除超过保留期的产物目录
+func Cleanup(retention time.Duration) (int, error) {
+	entries, err := os.ReadDir(Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read artifacts directory: %v", err)
+	}
+
+	removed := 0
+	deadline := time.Now().Add(-retention)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || info.ModTime().After(deadline) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(Dir, entry.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove artifacts %s: %v", entry.Name(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// StartCleanup 启动后台任务，定期按保留天数清理过期产物
+func StartCleanup() {
+	go func() {
+		for {
+			days := sysconfig.GetInt("artifacts.retention_days", 7)
+			if days > 0 {
+				if removed, err := Cleanup(time.Duration(days) * 24 * time.Hour); err != nil {
+					log.Printf("Failed to clean up artifacts: %v", err)
+				} else if removed > 0 {
+					log.Printf("🧹 Removed %d expired artifact directories", removed)
+				}
+			}
+			time.Sleep(cleanupInterval)
+		}
+	}()
+}
+
+// addToZip 将单个文件写入压缩包
+func addToZip(zw *zip.Writer, path string, a Artifact) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	header := &zip.FileHeader{
+		Name:     a.Path,
+		Method:   zip.Deflate,
+		Modified: a.ModTime,
+	}
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// moveFile 移动文件，跨文件系统时回退为复制
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,237 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// changeKey 请求上下文中保存修改内容的键
+	changeKey = "audit.change"
+	// actorKey 请求上下文中保存操作者名称的键，用于认证前的请求（如登录）
+	actorKey = "audit.actor"
+	// exportBatchSize 导出时每批读取的事件数
+	exportBatchSize = 500
+)
+
+// ignoredFields 不参与比较的字段
+var ignoredFields = map[string]bool{
+	"updated_at":    true,
+	"last_call_at":  true,
+	"call_count":    true,
+	"next_run_at":   true,
+	"last_login_at": true,
+}
+
+// actionOverrides 无法从路由推导出合适名称的操作
+var actionOverrides = map[string]string{
+	"POST /api/import":            "bundle.import",
+	"GET /api/auth/oidc/callback": "auth.oidc.login",
+}
+
+// change 处理函数提供的操作对象和修改前后的状态
+type change struct {
+	target string
+	before interface{}
+	after  interface{}
+}
+
+// SetChange 由处理函数记录操作对象和修改前后的状态，新建时 before 为 nil，删除时 after 为 nil
+func SetChange(c *gin.Context, target string, before, after interface{}) {
+	c.Set(changeKey, &change{target: target, before: before, after: after})
+}
+
+// SetActor 记录认证前请求的操作者名称，如登录使用的用户名
+func SetActor(c *gin.Context, name string) {
+	c.Set(actorKey, name)
+}
+
+// Record 在请求处理完成后写入审计事件
+func Record(c *gin.Context) {
+	RecordAction(c, Action(c.Request.Method, c.FullPath()), "")
+}
+
+// RecordAction 以指定的操作名称写入审计事件，用于不对应某个路由的事件（如认证锁定）。
+// target 为空时与 Record 一样取路由参数或处理函数记录的操作对象
+func RecordAction(c *gin.Context, action, target string) {
+	event := models.AuditEvent{
+		Action:    action,
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Target:    c.Param("id"),
+		Status:    c.Writer.Status(),
+		IP:        c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+
+	event.Actor, event.ActorType = actor(c)
+	if value, ok := c.Get(changeKey); ok {
+		if ch, ok := value.(*change); ok {
+			if ch.target != "" {
+				event.Target = ch.target
+			}
+			event.Changes = Diff(ch.before, ch.after)
+		}
+	}
+	if target != "" {
+		event.Target = target
+	}
+
+	if err := database.GetDB().Create(&event).Error; err != nil {
+		log.Printf("Failed to record audit event %s: %v", event.Action, err)
+	}
+}
+
+// actor 获取操作者名称和类型
+func actor(c *gin.Context) (string, string) {
+	identity := auth.CurrentIdentity(c)
+	switch {
+	case identity == nil:
+		name, _ := c.Get(actorKey)
+		s, _ := name.(string)
+		return s, "anonymous"
+	case identity.Token != nil:
+		return identity.Name(), "token"
+	case identity.User != nil:
+		return identity.Name(), "user"
+	default:
+		return identity.Name(), "key"
+	}
+}
+
+// Action 根据路由推导操作名称：POST 到具体动作的路由使用动作名（如 scripts.toggle），
+// 其余使用资源名加 create / update / delete（如 scripts.update）
+func Action(method, fullPath string) string {
+	if action, ok := actionOverrides[method+" "+fullPath]; ok {
+		return action
+	}
+
+	var segments []string
+	parts := strings.Split(strings.TrimPrefix(fullPath, "/api/"), "/")
+	for _, part := range parts {
+		if part != "" && !strings.HasPrefix(part, ":") {
+			segments = append(segments, part)
+		}
+	}
+	if len(segments) == 0 {
+		return strings.ToLower(method)
+	}
+
+	last := parts[len(parts)-1]
+	if method == "POST" && len(segments) > 1 && !strings.HasPrefix(last, ":") {
+		return strings.Join(segments, ".")
+	}
+
+	verb := strings.ToLower(method)
+	switch method {
+	case "POST":
+		verb = "create"
+	case "PUT", "PATCH":
+		verb = "update"
+	case "DELETE":
+		verb = "delete"
+	}
+	return strings.Join(segments, ".") + "." + verb
+}
+
+// Diff 比较修改前后的状态，返回有变化的字段。两者先序列化为 JSON 对象再逐字段比较
+func Diff(before, after interface{}) models.AuditChanges {
+	if before == nil && after == nil {
+		return nil
+	}
+	from := toMap(before)
+	to := toMap(after)
+
+	changes := make(models.AuditChanges)
+	for key, value := range from {
+		if ignoredFields[key] {
+			continue
+		}
+		if newValue, ok := to[key]; !ok || !reflect.DeepEqual(value, newValue) {
+			changes[key] = models.AuditChange{From: value, To: to[key]}
+		}
+	}
+	for key, value := range to {
+		if ignoredFields[key] {
+			continue
+		}
+		if _, ok := from[key]; !ok {
+			changes[key] = models.AuditChange{To: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// toMap 将任意值转换为 JSON 对象，非对象的值放在 value 字段下
+func toMap(v interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	if v == nil {
+		return result
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return result
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		var value interface{}
+		json.Unmarshal(data, &value)
+		return map[string]interface{}{"value": value}
+	}
+	return result
+}
+
+// Query 按条件构建审计事件查询
+func Query(q models.AuditQuery) *gorm.DB {
+	query := database.GetDB().Model(&models.AuditEvent{})
+	if q.Actor != "" {
+		query = query.Where("actor = ?", q.Actor)
+	}
+	if q.Action != "" {
+		query = query.Where("action = ? OR action LIKE ?", q.Action, q.Action+".%")
+	}
+	if q.Target != "" {
+		query = query.Where("target = ?", q.Target)
+	}
+	if q.IP != "" {
+		query = query.Where("ip = ?", q.IP)
+	}
+	if q.From != nil {
+		query = query.Where("created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		query = query.Where("created_at < ?", *q.To)
+	}
+	return query
+}
+
+// Export 按条件将审计事件以 JSON Lines 格式写入 w，按时间先后排列
+func Export(q models.AuditQuery, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for offset := 0; ; offset += exportBatchSize {
+		var batch []models.AuditEvent
+		if err := Query(q).Order("created_at ASC, id ASC").Offset(offset).Limit(exportBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+		for _, event := range batch {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+	}
+}
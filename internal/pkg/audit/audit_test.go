@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"hook-panel/internal/models"
+)
+
+func TestAction(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"POST", "/api/scripts", "scripts.create"},
+		{"PUT", "/api/scripts/:id", "scripts.update"},
+		{"DELETE", "/api/scripts/:id", "scripts.delete"},
+		{"POST", "/api/scripts/:id/toggle", "scripts.toggle"},
+		{"POST", "/api/scripts/:id/execute", "scripts.execute"},
+		{"POST", "/api/scripts/:id/versions/:version/restore", "scripts.versions.restore"},
+		{"DELETE", "/api/scripts/:id/logs", "scripts.logs.delete"},
+		{"PUT", "/api/users/:id/permissions", "users.permissions.update"},
+		{"POST", "/api/auth/login", "auth.login"},
+		{"POST", "/api/tokens", "tokens.create"},
+		{"DELETE", "/api/lockouts/:ip", "lockouts.delete"},
+		{"PATCH", "/api/config", "config.update"},
+		{"POST", "/api/import", "bundle.import"},
+		{"GET", "/api/auth/oidc/callback", "auth.oidc.login"},
+		{"GET", "/api/", "get"},
+	}
+	for _, tt := range tests {
+		if got := Action(tt.method, tt.path); got != tt.want {
+			t.Errorf("Action(%q, %q) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type item struct {
+		Name      string   `json:"name"`
+		Enabled   bool     `json:"enabled"`
+		Tags      []string `json:"tags"`
+		Secret    string   `json:"-"`
+		UpdatedAt string   `json:"updated_at"`
+		CallCount int      `json:"call_count"`
+	}
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   models.AuditChanges
+	}{
+		{
+			name: "both nil",
+		},
+		{
+			name:   "no changes",
+			before: item{Name: "a", Tags: []string{"x"}},
+			after:  item{Name: "a", Tags: []string{"x"}},
+		},
+		{
+			name:   "ignored fields",
+			before: item{Name: "a", UpdatedAt: "1", CallCount: 1, Secret: "s1"},
+			after:  item{Name: "a", UpdatedAt: "2", CallCount: 2, Secret: "s2"},
+		},
+		{
+			name:   "changed fields",
+			before: item{Name: "a", Tags: []string{"x"}},
+			after:  item{Name: "b", Enabled: true, Tags: []string{"x", "y"}},
+			want: models.AuditChanges{
+				"name":    {From: "a", To: "b"},
+				"enabled": {From: false, To: true},
+				"tags":    {From: []interface{}{"x"}, To: []interface{}{"x", "y"}},
+			},
+		},
+		{
+			name:  "create",
+			after: map[string]interface{}{"name": "a", "updated_at": "1"},
+			want: models.AuditChanges{
+				"name": {To: "a"},
+			},
+		},
+		{
+			name:   "delete",
+			before: map[string]interface{}{"name": "a"},
+			want: models.AuditChanges{
+				"name": {From: "a"},
+			},
+		},
+		{
+			name:   "added and removed keys",
+			before: map[string]interface{}{"old": 1},
+			after:  map[string]interface{}{"new": 2},
+			want: models.AuditChanges{
+				"old": {From: float64(1)},
+				"new": {To: float64(2)},
+			},
+		},
+		{
+			name:   "scalar values",
+			before: "a",
+			after:  "b",
+			want: models.AuditChanges{
+				"value": {From: "a", To: "b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Diff() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"hook-panel/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// identityKey 请求上下文中保存身份的键
+const identityKey = "auth.identity"
+
+// KeyIdentityName 使用访问密钥认证时记录的操作者名称
+const KeyIdentityName = "admin"
+
+// Identity 当前请求的身份，User 和 Token 都为 nil 时表示使用访问密钥认证
+type Identity struct {
+	User    *models.User     // 登录用户
+	Session *models.Session  // 登录会话
+	Token   *models.APIToken // API 令牌
+}
+
+// IsKey 是否使用访问密钥认证
+func (i *Identity) IsKey() bool {
+	return i.User == nil && i.Token == nil
+}
+
+// Name 操作者名称，记录到版本历史等位置
+func (i *Identity) Name() string {
+	if i.Token != nil {
+		return "token:" + i.Token.Name
+	}
+	if i.User == nil {
+		return KeyIdentityName
+	}
+	return i.User.Username
+}
+
+// SetIdentity 保存当前请求的身份
+func SetIdentity(c *gin.Context, identity *Identity) {
+	c.Set(identityKey, identity)
+}
+
+// CurrentIdentity 获取当前请求的身份，未经认证中间件时返回 nil
+func CurrentIdentity(c *gin.Context) *Identity {
+	if value, ok := c.Get(identityKey); ok {
+		if identity, ok := value.(*Identity); ok {
+			return identity
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// roleLevels 角色权限等级，等级高的角色拥有等级低的角色的全部权限
+var roleLevels = map[string]int{
+	models.RoleViewer:   1,
+	models.RoleOperator: 2,
+	models.RoleEditor:   3,
+	models.RoleAdmin:    4,
+}
+
+// ValidRole 是否为有效角色
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// roleScopes 角色对应的 API 令牌权限范围，令牌的各个权限范围相互独立
+var roleScopes = map[string]string{
+	models.RoleViewer:   models.ScopeRead,
+	models.RoleOperator: models.ScopeExecute,
+	models.RoleEditor:   models.ScopeManage,
+}
+
+// RoleAtLeast 角色 role 是否拥有 required 角色的权限
+func RoleAtLeast(role, required string) bool {
+	level, ok := roleLevels[required]
+	return ok && roleLevels[role] >= level
+}
+
+// Role 全局角色，使用访问密钥认证时为 admin，API 令牌为权限范围对应的最高角色
+func (i *Identity) Role() string {
+	if i.Token != nil {
+		role := ""
+		for _, r := range []string{models.RoleViewer, models.RoleOperator, models.RoleEditor} {
+			if i.Token.HasScope(roleScopes[r]) {
+				role = r
+			}
+		}
+		return role
+	}
+	if i.User == nil {
+		return models.RoleAdmin
+	}
+	if !ValidRole(i.User.Role) {
+		return models.RoleViewer
+	}
+	return i.User.Role
+}
+
+// HasRole 全局角色是否拥有 required 角色的权限。
+// 限定脚本的 API 令牌在全局范围内最多只能查看
+func (i *Identity) HasRole(required string) bool {
+	if i.Token != nil {
+		if i.Token.Restricted() && required != models.RoleViewer {
+			return false
+		}
+		return i.Token.HasScope(roleScopes[required])
+	}
+	return RoleAtLeast(i.Role(), required)
+}
+
+// ScriptScope 限定脚本的 API 令牌可以访问的脚本 ID，不限脚本时返回 nil
+func (i *Identity) ScriptScope() []string {
+	if i.Token != nil && i.Token.Restricted() {
+		return i.Token.ScriptIDs
+	}
+	return nil
+}
+
+// scriptRole 获取登录用户在脚本上的有效角色：脚本授权优先于分组授权，分组授权优先于全局角色，
+// admin 不受授权限制。scriptID 为空时只按分组计算，用于创建脚本
+func (i *Identity) scriptRole(scriptID, group string) (string, error) {
+	role := i.Role()
+	if role == models.RoleAdmin {
+		return role, nil
+	}
+
+	db := database.GetDB()
+	var permission models.ScriptPermission
+	if scriptID != "" {
+		err := db.Where("user_id = ? AND script_id = ?", i.User.ID, scriptID).First(&permission).Error
+		if err == nil {
+			return permission.Role, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+	}
+	if group != "" {
+		err := db.Where("user_id = ? AND group_name = ?", i.User.ID, group).First(&permission).Error
+		if err == nil {
+			return permission.Role, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+	}
+	return role, nil
+}
+
+// CanScript 在脚本上是否拥有 required 角色的权限
+func (i *Identity) CanScript(scriptID, group, required string) (bool, error) {
+	if i.Token != nil {
+		return i.Token.AllowsScript(scriptID) && i.Token.HasScope(roleScopes[required]), nil
+	}
+	role, err := i.scriptRole(scriptID, group)
+	if err != nil {
+		return false, err
+	}
+	return RoleAtLeast(role, required), nil
+}
+
+// ValidateGrants 校验授权列表，script_id 与 group 必须且只能设置一个，且脚本必须存在
+func ValidateGrants(grants []models.PermissionGrant) error {
+	seen := make(map[string]bool)
+	for idx, grant := range grants {
+		if (grant.ScriptID == "") == (grant.Group == "") {
+			return fmt.Errorf("permissions[%d]: exactly one of script_id and group must be set", idx)
+		}
+		key := "script:" + grant.ScriptID
+		if grant.Group != "" {
+			key = "group:" + grant.Group
+		}
+		if seen[key] {
+			return fmt.Errorf("permissions[%d]: duplicate grant for %s", idx, key)
+		}
+		seen[key] = true
+
+		if grant.ScriptID != "" {
+			var count int64
+			if err := database.GetDB().Model(&models.Script{}).Where("id = ?", grant.ScriptID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("permissions[%d]: script %s not found", idx, grant.ScriptID)
+			}
+		}
+	}
+	return nil
+}
+
+// ReplaceGrants 替换用户的全部授权
+func ReplaceGrants(userID string, grants []models.PermissionGrant) ([]models.ScriptPermission, error) {
+	permissions := make([]models.ScriptPermission, 0, len(grants))
+	for _, grant := range grants {
+		permissions = append(permissions, models.ScriptPermission{
+			UserID:   userID,
+			ScriptID: grant.ScriptID,
+			Group:    grant.Group,
+			Role:     grant.Role,
+		})
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.ScriptPermission{}).Error; err != nil {
+			return err
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+		return tx.Create(&permissions).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save permissions: %v", err)
+	}
+	return permissions, nil
+}
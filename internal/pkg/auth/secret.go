@@ -3,34 +3,86 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	SecretKeyFile   = "./data/secret.key"
 	SecretKeyLength = 32 // 32字节 = 64个十六进制字符
+
+	// PreviousKeyFile 轮换后仍在宽限期内的旧密钥，内容为 "<密钥> <过期时间戳>"
+	PreviousKeyFile = "./data/secret.key.previous"
+)
+
+const (
+	// SecretKeyEnv 直接提供访问密钥的环境变量
+	SecretKeyEnv = "HOOK_PANEL_SECRET_KEY"
+	// SecretKeyFileEnv 指定密钥文件路径的环境变量，如挂载的 Docker / Kubernetes secret
+	SecretKeyFileEnv = "HOOK_PANEL_SECRET_KEY_FILE"
+	// minExternalKeyLength 外部提供的密钥的最小长度
+	minExternalKeyLength = 16
 )
 
-var secretKey string
+// ErrExternalKey 密钥由外部提供，不能在程序内轮换
+var ErrExternalKey = errors.New("access key is supplied externally and cannot be rotated here")
+
+var (
+	keyMu     sync.RWMutex
+	secretKey string
+	// keySource 密钥来源，外部提供时为环境变量名或文件路径，为空表示使用 SecretKeyFile
+	keySource string
+
+	// previousKey 轮换前的密钥，在 previousExpiresAt 之前与新密钥同时有效
+	previousKey       string
+	previousExpiresAt time.Time
+)
 
-// InitSecretKey 初始化密钥
-func InitSecretKey() error {
+// InitSecretKey 初始化密钥。keyFile 为命令行指定的密钥文件，优先级依次为
+// keyFile、环境变量 HOOK_PANEL_SECRET_KEY、HOOK_PANEL_SECRET_KEY_FILE，都未提供时使用 SecretKeyFile。
+// 完整密钥只在首次生成时输出，其余情况只输出掩码
+func InitSecretKey(keyFile string) error {
 	// 确保 data 目录存在
 	dataDir := "./data"
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return fmt.Errorf("failed to create data directory: %v", err)
 	}
 
+	if keyFile == "" {
+		if key := strings.TrimSpace(os.Getenv(SecretKeyEnv)); key != "" {
+			if err := setExternalKey(key, SecretKeyEnv); err != nil {
+				return err
+			}
+			return finishInit(false)
+		}
+		keyFile = os.Getenv(SecretKeyFileEnv)
+	}
+	if keyFile != "" {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read secret key file: %v", err)
+		}
+		if err := setExternalKey(strings.TrimSpace(string(content)), keyFile); err != nil {
+			return err
+		}
+		return finishInit(false)
+	}
+
+	generated := false
 	// 检查密钥文件是否存在
 	if _, err := os.Stat(SecretKeyFile); os.IsNotExist(err) {
 		// 文件不存在，生成新密钥
 		if err := generateAndSaveSecretKey(); err != nil {
 			return fmt.Errorf("failed to generate secret key: %v", err)
 		}
+		generated = true
 		log.Println("🔑 Generated new access key")
 	} else {
 		// File exists, load secret key
@@ -44,17 +96,57 @@ func InitSecretKey() error {
 			if err := generateAndSaveSecretKey(); err != nil {
 				return fmt.Errorf("failed to regenerate secret key: %v", err)
 			}
+			generated = true
 			log.Println("🔑 Secret key file is empty, regenerated access key")
 		} else {
 			log.Println("🔑 Loaded existing access key")
 		}
 	}
+	return finishInit(generated)
+}
+
+// setExternalKey 使用外部提供的密钥
+func setExternalKey(key, source string) error {
+	if len(key) < minExternalKeyLength {
+		return fmt.Errorf("access key from %s must be at least %d characters", source, minExternalKeyLength)
+	}
+	secretKey = key
+	keySource = source
+	log.Printf("🔑 Loaded access key from %s", source)
+	return nil
+}
+
+// finishInit 加载宽限期内的旧密钥并输出密钥，只有新生成的密钥会完整输出
+func finishInit(generated bool) error {
+	if err := loadPreviousKey(); err != nil {
+		log.Printf("⚠️ Failed to load previous access key: %v", err)
+	} else if previousKey != "" {
+		log.Printf("🔑 Previous access key remains valid until %s", previousExpiresAt.Format(time.RFC3339))
+	}
 
-	// Output secret key content
-	log.Printf("🔐 Access key: %s", secretKey)
+	if generated {
+		log.Printf("🔐 Access key: %s (shown only once, also saved in %s)", secretKey, GetSecretKeyFilePath())
+	} else {
+		log.Printf("🔐 Access key: %s", MaskKey(secretKey))
+	}
 	return nil
 }
 
+// MaskKey 只保留密钥首尾各 4 个字符，用于日志输出
+func MaskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + strings.Repeat("*", 8) + key[len(key)-4:]
+}
+
+// ExternalKey 密钥是否由环境变量或指定文件提供
+func ExternalKey() bool {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return keySource != ""
+}
+
 // generateAndSaveSecretKey 生成并保存密钥
 func generateAndSaveSecretKey() error {
 	// 生成32字节的随机密钥
@@ -85,22 +177,107 @@ func loadSecretKey() error {
 	return nil
 }
 
+// loadPreviousKey 加载宽限期内的旧密钥，已过期时删除文件
+func loadPreviousKey() error {
+	content, err := os.ReadFile(PreviousKeyFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read previous key file: %v", err)
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) != 2 {
+		return fmt.Errorf("malformed previous key file")
+	}
+	expires, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed previous key file: %v", err)
+	}
+	if time.Now().After(time.Unix(expires, 0)) {
+		os.Remove(PreviousKeyFile)
+		return nil
+	}
+	previousKey = fields[0]
+	previousExpiresAt = time.Unix(expires, 0)
+	return nil
+}
+
 // GetSecretKey 获取当前密钥
 func GetSecretKey() string {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
 	return secretKey
 }
 
+// SecretKeys 获取当前有效的全部密钥，第一个为当前密钥，其后为宽限期内的旧密钥
+func SecretKeys() []string {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	if previousKey != "" && time.Now().Before(previousExpiresAt) {
+		return []string{secretKey, previousKey}
+	}
+	return []string{secretKey}
+}
+
+// RotateSecretKey 生成新密钥，旧密钥在 grace 时间内仍然有效，grace 为 0 时立即失效。
+// 宽限期内再次轮换时，更早的密钥立即失效。返回旧密钥的失效时间
+func RotateSecretKey(grace time.Duration) (time.Time, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if keySource != "" {
+		return time.Time{}, ErrExternalKey
+	}
+
+	oldKey := secretKey
+	expiresAt := time.Now().Add(grace)
+	if grace > 0 {
+		content := fmt.Sprintf("%s %d", oldKey, expiresAt.Unix())
+		if err := os.WriteFile(PreviousKeyFile, []byte(content), 0600); err != nil {
+			return time.Time{}, fmt.Errorf("failed to save previous key file: %v", err)
+		}
+	} else if err := os.Remove(PreviousKeyFile); err != nil && !os.IsNotExist(err) {
+		return time.Time{}, fmt.Errorf("failed to remove previous key file: %v", err)
+	}
+
+	if err := generateAndSaveSecretKey(); err != nil {
+		secretKey = oldKey
+		return time.Time{}, err
+	}
+	if grace > 0 {
+		previousKey, previousExpiresAt = oldKey, expiresAt
+		log.Printf("🔄 Rotated access key, previous key valid until %s", expiresAt.Format(time.RFC3339))
+	} else {
+		previousKey, previousExpiresAt = "", time.Time{}
+		log.Println("🔄 Rotated access key, previous key revoked")
+	}
+	return expiresAt, nil
+}
+
 // RegenerateSecretKey 重新生成密钥
 func RegenerateSecretKey() error {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if keySource != "" {
+		return ErrExternalKey
+	}
 	if err := generateAndSaveSecretKey(); err != nil {
 		return err
 	}
-	log.Printf("🔄 Regenerated access key: %s", secretKey)
+	log.Printf("🔄 Regenerated access key: %s", MaskKey(secretKey))
 	return nil
 }
 
-// GetSecretKeyFilePath 获取密钥文件路径
+// GetSecretKeyFilePath 获取密钥文件路径，密钥来自环境变量时返回环境变量名
 func GetSecretKeyFilePath() string {
-	absPath, _ := filepath.Abs(SecretKeyFile)
+	if keySource == SecretKeyEnv {
+		return keySource
+	}
+	path := SecretKeyFile
+	if keySource != "" {
+		path = keySource
+	}
+	absPath, _ := filepath.Abs(path)
 	return absPath
 }
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// apiTokenPrefix API 令牌前缀
+	apiTokenPrefix = "hpt_"
+	// apiTokenDisplayLength 保存用于识别的令牌开头长度
+	apiTokenDisplayLength = 12
+	// lastUsedInterval 最近使用时间的更新间隔，避免每次请求都写数据库
+	lastUsedInterval = time.Minute
+)
+
+// ErrInvalidAPIToken API 令牌不存在或已过期
+var ErrInvalidAPIToken = errors.New("invalid or expired api token")
+
+// IsAPIToken 是否为 API 令牌格式
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, apiTokenPrefix)
+}
+
+// CreateAPIToken 创建 API 令牌，返回明文令牌，令牌只在此时可见。请求需先经过 ValidateTokenScope 校验
+func CreateAPIToken(req models.TokenCreateRequest, author string) (string, *models.APIToken, error) {
+	token, err := newToken(apiTokenPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+	apiToken := models.APIToken{
+		Name:      req.Name,
+		TokenHash: HashToken(token),
+		Prefix:    token[:apiTokenDisplayLength],
+		Scopes:    req.Scopes,
+		ScriptIDs: req.ScriptIDs,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: author,
+	}
+	if err := database.GetDB().Create(&apiToken).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to create api token: %v", err)
+	}
+	return token, &apiToken, nil
+}
+
+// ValidateTokenScope 校验令牌的权限范围、脚本限制和过期时间
+func ValidateTokenScope(scopes, scriptIDs []string, expiresAt *time.Time) error {
+	seen := make(map[string]bool)
+	for _, scope := range scopes {
+		if scope != models.ScopeRead && scope != models.ScopeExecute && scope != models.ScopeManage {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+		if seen[scope] {
+			return fmt.Errorf("duplicate scope: %s", scope)
+		}
+		seen[scope] = true
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	for idx, id := range scriptIDs {
+		for _, other := range scriptIDs[:idx] {
+			if other == id {
+				return fmt.Errorf("duplicate script id: %s", id)
+			}
+		}
+		var count int64
+		if err := database.GetDB().Model(&models.Script{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("script %s not found", id)
+		}
+	}
+	return nil
+}
+
+// LookupAPIToken 根据令牌查找有效的 API 令牌，并记录最近使用时间
+func LookupAPIToken(token string) (*models.APIToken, error) {
+	db := database.GetDB()
+	var apiToken models.APIToken
+	if err := db.Where("token_hash = ?", HashToken(token)).First(&apiToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if apiToken.ExpiresAt != nil && now.After(*apiToken.ExpiresAt) {
+		return nil, ErrInvalidAPIToken
+	}
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= lastUsedInterval {
+		db.Model(&apiToken).UpdateColumn("last_used_at", now)
+		apiToken.LastUsedAt = &now
+	}
+	return &apiToken, nil
+}
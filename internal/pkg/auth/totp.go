@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/sysconfig"
+)
+
+// TOTP 参数（RFC 6238），与常见身份验证器应用的默认值一致
+const (
+	totpPeriod     = 30 // 时间步长（秒）
+	totpDigits     = 6
+	totpSkew       = 1  // 允许前后各偏差的时间步数
+	totpSecretSize = 20 // 密钥字节数（160 位，RFC 4226 推荐值）
+	totpIssuer     = "Hook Panel"
+
+	// RecoveryCodeCount 每次生成的恢复码数量
+	RecoveryCodeCount = 10
+)
+
+var (
+	// ErrInvalidTOTP 验证码或恢复码错误
+	ErrInvalidTOTP = errors.New("invalid verification code")
+	// ErrTOTPNotPending 未开始绑定
+	ErrTOTPNotPending = errors.New("two-factor setup has not been started")
+)
+
+// totpEncoding 密钥使用不带填充的 Base32 编码
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPRequired 是否强制所有账号启用两步验证
+func TOTPRequired() bool {
+	return sysconfig.GetBool("auth.require_totp", false)
+}
+
+// EnrollmentRequired 账号是否必须先绑定两步验证才能在该会话中使用其他功能。
+// 单点登录会话的多因素认证由身份提供方负责，不受此限制；同一账号使用密码登录时仍需绑定
+func EnrollmentRequired(user *models.User, session *models.Session) bool {
+	return !user.TOTPEnabled && session.Method != models.SessionOIDC && TOTPRequired()
+}
+
+// BeginTOTPSetup 为用户生成新的待确认密钥，返回密钥和 otpauth 链接（用于生成二维码）
+func BeginTOTPSetup(user *models.User) (string, string, error) {
+	b := make([]byte, totpSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate secret: %v", err)
+	}
+	secret := totpEncoding.EncodeToString(b)
+	sealed, err := sysconfig.Encrypt(totpSecretKey(user.ID), secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt secret: %v", err)
+	}
+
+	if err := database.GetDB().Model(user).Updates(map[string]interface{}{
+		"totp_secret":    sealed,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		return "", "", fmt.Errorf("failed to save secret: %v", err)
+	}
+	return secret, ProvisioningURI(user.Username, secret), nil
+}
+
+// EnableTOTP 使用验证码确认待绑定的密钥，启用两步验证并返回新的恢复码
+func EnableTOTP(user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		return nil, ErrTOTPNotPending
+	}
+	secret, err := totpSecret(user)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := verifyTOTP(secret, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrInvalidTOTP
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := database.GetDB().Model(user).Updates(map[string]interface{}{
+		"totp_enabled":   true,
+		"totp_last_step": step,
+		"recovery_codes": hashes,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %v", err)
+	}
+	return codes, nil
+}
+
+// DisableTOTP 关闭两步验证并清除密钥和恢复码
+func DisableTOTP(user *models.User) error {
+	return database.GetDB().Model(user).Updates(map[string]interface{}{
+		"totp_enabled":   false,
+		"totp_secret":    "",
+		"totp_last_step": 0,
+		"recovery_codes": models.StringList{},
+	}).Error
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效
+func RegenerateRecoveryCodes(user *models.User) ([]string, error) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := database.GetDB().Model(user).Update("recovery_codes", hashes).Error; err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %v", err)
+	}
+	return codes, nil
+}
+
+// VerifySecondFactor 校验已启用两步验证的用户提交的验证码或恢复码。
+// 验证码不能重复使用，恢复码使用后即失效
+func VerifySecondFactor(user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return nil
+	}
+	code = strings.TrimSpace(code)
+	secret, err := totpSecret(user)
+	if err != nil {
+		return err
+	}
+
+	if step, ok := verifyTOTP(secret, code, time.Now(), user.TOTPLastStep); ok {
+		// 条件更新，防止同一验证码被并发请求重复使用
+		result := database.GetDB().Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidTOTP
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	hash := HashToken(normalizeRecoveryCode(code))
+	for i, stored := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			remaining := append(models.StringList{}, user.RecoveryCodes[:i]...)
+			remaining = append(remaining, user.RecoveryCodes[i+1:]...)
+			// 条件更新，防止同一恢复码被并发请求重复使用
+			result := database.GetDB().Model(&models.User{}).
+				Where("id = ? AND recovery_codes = ?", user.ID, user.RecoveryCodes).
+				Update("recovery_codes", remaining)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrInvalidTOTP
+			}
+			user.RecoveryCodes = remaining
+			return nil
+		}
+	}
+	return ErrInvalidTOTP
+}
+
+// EncryptTOTPSecrets 加密仍以明文保存的两步验证密钥，启动时调用
+func EncryptTOTPSecrets() error {
+	db := database.GetDB()
+	var users []models.User
+	if err := db.Select("id", "totp_secret").Where("totp_secret <> ''").Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to query two-factor secrets: %v", err)
+	}
+
+	count := 0
+	for _, user := range users {
+		if sysconfig.IsEncrypted(user.TOTPSecret) {
+			continue
+		}
+		sealed, err := sysconfig.Encrypt(totpSecretKey(user.ID), user.TOTPSecret)
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).Update("totp_secret", sealed).Error; err != nil {
+			return fmt.Errorf("failed to encrypt two-factor secret of user %s: %v", user.ID, err)
+		}
+		count++
+	}
+	if count > 0 {
+		log.Printf("🔒 Encrypted %d plaintext two-factor secret(s)", count)
+	}
+	return nil
+}
+
+// totpSecret 解密用户的两步验证密钥
+func totpSecret(user *models.User) (string, error) {
+	secret, err := sysconfig.Decrypt(totpSecretKey(user.ID), user.TOTPSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt two-factor secret: %v", err)
+	}
+	return secret, nil
+}
+
+// totpSecretKey 加密密钥时使用的附加数据，防止密文在用户之间互换
+func totpSecretKey(userID string) string {
+	return "totp_secret:" + userID
+}
+
+// ProvisioningURI 生成身份验证器应用使用的 otpauth 链接
+func ProvisioningURI(account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(totpIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPCode 计算指定时间步的验证码（RFC 6238 / RFC 4226 的 HOTP 截断）
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %v", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// verifyTOTP 在允许的偏差内校验验证码，只接受大于 lastStep 的时间步，返回匹配的时间步
+func verifyTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// newRecoveryCodes 生成恢复码，返回明文和用于保存的哈希
+func newRecoveryCodes() ([]string, models.StringList, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make(models.StringList, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery codes: %v", err)
+		}
+		raw := hex.EncodeToString(b)
+		code := raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		codes = append(codes, code)
+		hashes = append(hashes, HashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode 忽略恢复码中的分隔符和大小写
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/sysconfig"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA-1 使用的密钥 "12345678901234567890"
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA-1 测试向量，原值为 8 位，这里取后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// 密钥大小写和首尾空白不影响结果
+	if got, _ := TOTPCode(" "+strings.ToLower(rfcSecret)+" ", 59/totpPeriod); got != "287082" {
+		t.Errorf("TOTPCode with lowercase secret = %s, want 287082", got)
+	}
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode should reject an invalid secret")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	code := func(step int64) string {
+		c, err := TOTPCode(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(current), 0, current, true},
+		{"previous step within skew", code(current - 1), 0, current - 1, true},
+		{"next step within skew", code(current + 1), 0, current + 1, true},
+		{"two steps behind", code(current - 2), 0, 0, false},
+		{"two steps ahead", code(current + 2), 0, 0, false},
+		{"replay of the same step", code(current), current, 0, false},
+		{"replay of an earlier step", code(current - 1), current, 0, false},
+		{"later step after use", code(current + 1), current, current + 1, true},
+		{"wrong code", "000000", 0, 0, false},
+		{"wrong length", code(current)[:5], 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := verifyTOTP(rfcSecret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("verifyTOTP() = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSecondFactorReplay(t *testing.T) {
+	user := setupTOTPUser(t)
+
+	code, err := currentCode(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySecondFactor(user, code); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := VerifySecondFactor(user, code); !errors.Is(err, ErrInvalidTOTP) {
+		t.Fatalf("replay in the same session: err = %v, want ErrInvalidTOTP", err)
+	}
+
+	// 其他请求加载的旧用户数据同样不能重复使用该时间步
+	var stale models.User
+	if err := database.GetDB().First(&stale, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	stale.TOTPLastStep = 0
+	if err := VerifySecondFactor(&stale, code); !errors.Is(err, ErrInvalidTOTP) {
+		t.Fatalf("concurrent replay: err = %v, want ErrInvalidTOTP", err)
+	}
+}
+
+func TestRecoveryCodeSingleUse(t *testing.T) {
+	user := setupTOTPUser(t)
+
+	codes, err := RegenerateRecoveryCodes(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.GetDB().First(user, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySecondFactor(user, strings.ToUpper(codes[0])); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := VerifySecondFactor(user, codes[0]); !errors.Is(err, ErrInvalidTOTP) {
+		t.Fatalf("reused recovery code: err = %v, want ErrInvalidTOTP", err)
+	}
+	if len(user.RecoveryCodes) != RecoveryCodeCount-1 {
+		t.Errorf("%d recovery codes left, want %d", len(user.RecoveryCodes), RecoveryCodeCount-1)
+	}
+}
+
+func TestTOTPSecretEncrypted(t *testing.T) {
+	user := setupTOTPUser(t)
+	if !sysconfig.IsEncrypted(user.TOTPSecret) {
+		t.Fatalf("stored secret %q is not encrypted", user.TOTPSecret)
+	}
+
+	// 密文不能用于其他用户
+	other := *user
+	other.ID = "other"
+	if _, err := totpSecret(&other); err == nil {
+		t.Error("secret of one user must not decrypt for another")
+	}
+}
+
+// setupTOTPUser 使用内存数据库创建已启用两步验证的用户
+func setupTOTPUser(t *testing.T) *models.User {
+	t.Helper()
+	t.Setenv(sysconfig.ConfigKeyEnv, strings.Repeat("ab", 32))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接各自独立，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.User{}, &models.SystemConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+
+	user := &models.User{Username: "alice", Role: models.RoleAdmin}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := BeginTOTPSetup(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(user, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	secret, err := totpSecret(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 使用上一个时间步确认绑定，留出当前时间步供测试使用
+	code, err := TOTPCode(secret, time.Now().Unix()/totpPeriod-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnableTOTP(user, code); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(user, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// currentCode 计算用户当前时间步的验证码
+func currentCode(user *models.User) (string, error) {
+	secret, err := totpSecret(user)
+	if err != nil {
+		return "", err
+	}
+	return TOTPCode(secret, time.Now().Unix()/totpPeriod)
+}
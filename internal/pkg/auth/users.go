@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/sysconfig"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// MinPasswordLength 密码最小长度
+	MinPasswordLength = 8
+	// sessionTokenPrefix 会话令牌前缀，便于与访问密钥区分
+	sessionTokenPrefix = "hps_"
+	// defaultSessionTTL 默认会话有效期（小时）
+	defaultSessionTTL = 24
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误（包括用户已禁用）
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidSession 会话不存在或已过期
+	ErrInvalidSession = errors.New("invalid or expired session")
+	// ErrWeakPassword 密码不满足要求
+	ErrWeakPassword = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+)
+
+var (
+	// dummyHash 用户不存在时参与比较的哈希，使登录耗时与用户存在时一致
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// HashPassword 校验密码强度并生成 bcrypt 哈希
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 校验用户密码
+func CheckPassword(user *models.User, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+// Authenticate 使用用户名和密码认证
+func Authenticate(username, password string) (*models.User, error) {
+	var user models.User
+	if err := database.GetDB().Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			dummyHashOnce.Do(func() {
+				dummyHash, _ = bcrypt.GenerateFromPassword([]byte("hook-panel"), bcrypt.DefaultCost)
+			})
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if !CheckPassword(&user, password) || user.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+// CreateSession 为用户创建会话，返回明文令牌，令牌只在此时可见
+func CreateSession(user *models.User, method, ip, userAgent string) (string, *models.Session, error) {
+	token, err := newToken(sessionTokenPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ttl := sysconfig.GetInt("auth.session_ttl_hours", defaultSessionTTL)
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	now := time.Now()
+	session := models.Session{
+		UserID:    user.ID,
+		TokenHash: HashToken(token),
+		Method:    method,
+		IP:        ip,
+		UserAgent: userAgent,
+		ExpiresAt: now.Add(time.Duration(ttl) * time.Hour),
+	}
+
+	db := database.GetDB()
+	if err := db.Create(&session).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to create session: %v", err)
+	}
+	db.Model(user).Update("last_login_at", now)
+
+	// 顺便清理过期会话
+	db.Where("expires_at < ?", now).Delete(&models.Session{})
+	return token, &session, nil
+}
+
+// IsSessionToken 是否为会话令牌格式
+func IsSessionToken(token string) bool {
+	return strings.HasPrefix(token, sessionTokenPrefix)
+}
+
+// LookupSession 根据令牌查找有效会话及其用户
+func LookupSession(token string) (*models.User, *models.Session, error) {
+	db := database.GetDB()
+	var session models.Session
+	if err := db.Where("token_hash = ?", HashToken(token)).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidSession
+		}
+		return nil, nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		db.Delete(&session)
+		return nil, nil, ErrInvalidSession
+	}
+
+	var user models.User
+	if err := db.First(&user, "id = ?", session.UserID).Error; err != nil || user.Disabled {
+		return nil, nil, ErrInvalidSession
+	}
+	return &user, &session, nil
+}
+
+// DeleteSession 删除会话
+func DeleteSession(sessionID string) error {
+	return database.GetDB().Delete(&models.Session{}, "id = ?", sessionID).Error
+}
+
+// DeleteUserSessions 删除用户的全部会话，exceptID 不为空时保留该会话
+func DeleteUserSessions(userID, exceptID string) error {
+	query := database.GetDB().Where("user_id = ?", userID)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	return query.Delete(&models.Session{}).Error
+}
+
+// HashToken 计算令牌的 SHA-256，数据库中只保存哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken 生成带前缀的随机令牌
+func newToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
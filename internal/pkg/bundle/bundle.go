@@ -0,0 +1,201 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/file"
+)
+
+const (
+	// FormatVersion 当前脚本包的格式版本，导入时拒绝更高版本的脚本包
+	FormatVersion = 1
+	// MaxSize 导入的脚本包大小上限
+	MaxSize = 32 << 20
+	// manifestName 清单文件名
+	manifestName = "manifest.json"
+	// contentDir 脚本内容文件所在目录
+	contentDir = "scripts"
+)
+
+// Manifest 脚本包清单
+type Manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Scripts    []Entry   `json:"scripts"`
+}
+
+// Entry 清单中的脚本，内容保存在 File 指向的文件中
+type Entry struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Group       string                  `json:"group"`
+	Executor    string                  `json:"executor"`
+	Enabled     bool                    `json:"enabled"`
+	Parameters  models.ScriptParameters `json:"parameters"`
+	Env         models.StringMap        `json:"env"`
+	Retry       models.RetryPolicy      `json:"retry"`
+	Schedule    models.CronSchedule     `json:"schedule"`
+	Chain       models.ScriptChain      `json:"chain"`
+	Watch       models.FileWatch        `json:"watch"`
+	File        string                  `json:"file"`
+}
+
+// Bundle 读取后的脚本包
+type Bundle struct {
+	Manifest
+	Contents map[string]string // 按脚本 ID 索引的内容
+}
+
+// Export 将脚本打包写入 w，ids 为空时导出全部脚本
+func Export(ids []string, w io.Writer) error {
+	query := database.GetDB().Order("created_at ASC")
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	var scripts []models.Script
+	if err := query.Find(&scripts).Error; err != nil {
+		return fmt.Errorf("failed to query scripts: %v", err)
+	}
+
+	manifest := Manifest{
+		Version:    FormatVersion,
+		ExportedAt: time.Now(),
+		Scripts:    make([]Entry, 0, len(scripts)),
+	}
+	contents := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		content, err := file.ReadScriptContent(script.ID)
+		if err != nil {
+			return err
+		}
+		schedule := script.Schedule
+		schedule.LastFiredAt = nil
+
+		manifest.Scripts = append(manifest.Scripts, Entry{
+			ID:          script.ID,
+			Name:        script.Name,
+			Description: script.Description,
+			Group:       script.Group,
+			Executor:    script.Executor,
+			Enabled:     script.Enabled,
+			Parameters:  script.Parameters,
+			Env:         script.Env,
+			Retry:       script.Retry,
+			Schedule:    schedule,
+			Chain:       script.Chain,
+			Watch:       script.Watch,
+			File:        path.Join(contentDir, script.ID+".txt"),
+		})
+		contents = append(contents, content)
+	}
+
+	zw := zip.NewWriter(w)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		zw.Close()
+		return err
+	}
+	if err := writeZipFile(zw, manifestName, data, manifest.ExportedAt); err != nil {
+		zw.Close()
+		return err
+	}
+	for i, entry := range manifest.Scripts {
+		if err := writeZipFile(zw, entry.File, []byte(contents[i]), manifest.ExportedAt); err != nil {
+			zw.Close()
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Read 解析脚本包，校验清单格式并读取全部脚本内容
+func Read(data []byte) (*Bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip archive: %v", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifestFile, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing", manifestName)
+	}
+	manifestData, err := readZipFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{Contents: make(map[string]string)}
+	if err := json.Unmarshal(manifestData, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", manifestName, err)
+	}
+	if b.Version < 1 || b.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+
+	for _, entry := range b.Scripts {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("script %q has no id", entry.Name)
+		}
+		if !file.ValidScriptID(entry.ID) {
+			return nil, fmt.Errorf("script %q has invalid id %q", entry.Name, entry.ID)
+		}
+		if _, ok := b.Contents[entry.ID]; ok {
+			return nil, fmt.Errorf("duplicate script id %s", entry.ID)
+		}
+		f, ok := files[entry.File]
+		if !ok {
+			return nil, fmt.Errorf("content file %q of script %q is missing", entry.File, entry.Name)
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		b.Contents[entry.ID] = string(content)
+	}
+	return b, nil
+}
+
+// writeZipFile 将单个文件写入压缩包
+func writeZipFile(zw *zip.Writer, name string, data []byte, modified time.Time) error {
+	dst, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(data)
+	return err
+}
+
+// readZipFile 读取压缩包中的单个文件，限制解压后的大小
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", f.Name, err)
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	return data, nil
+}
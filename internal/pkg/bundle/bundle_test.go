@@ -0,0 +1,140 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// buildZip 按文件名和内容生成压缩包
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// manifestJSON 生成包含指定脚本的清单
+func manifestJSON(t *testing.T, version int, entries ...Entry) string {
+	t.Helper()
+	data, err := json.Marshal(Manifest{Version: version, Scripts: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRead(t *testing.T) {
+	valid := Entry{ID: "deploy_01", Name: "deploy", File: "scripts/deploy_01.txt"}
+	other := Entry{ID: "backup-02", Name: "backup", File: "scripts/backup-02.txt"}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: buildZip(t, map[string]string{
+				manifestName:            manifestJSON(t, FormatVersion, valid, other),
+				"scripts/deploy_01.txt": "echo deploy",
+				"scripts/backup-02.txt": "echo backup",
+			}),
+		},
+		{
+			name:    "not a zip",
+			data:    []byte("plain text"),
+			wantErr: "not a zip archive",
+		},
+		{
+			name:    "missing manifest",
+			data:    buildZip(t, map[string]string{"scripts/deploy_01.txt": "echo"}),
+			wantErr: "manifest.json is missing",
+		},
+		{
+			name:    "invalid manifest",
+			data:    buildZip(t, map[string]string{manifestName: "{"}),
+			wantErr: "invalid manifest.json",
+		},
+		{
+			name:    "unsupported version",
+			data:    buildZip(t, map[string]string{manifestName: manifestJSON(t, FormatVersion+1)}),
+			wantErr: "unsupported bundle version",
+		},
+		{
+			name: "empty id",
+			data: buildZip(t, map[string]string{
+				manifestName:    manifestJSON(t, FormatVersion, Entry{Name: "x", File: "scripts/x.txt"}),
+				"scripts/x.txt": "echo",
+			}),
+			wantErr: "has no id",
+		},
+		{
+			name: "path traversal id",
+			data: buildZip(t, map[string]string{
+				manifestName:            manifestJSON(t, FormatVersion, valid, Entry{ID: "../../x", Name: "x", File: "scripts/x.txt"}),
+				"scripts/deploy_01.txt": "echo deploy",
+				"scripts/x.txt":         "echo",
+			}),
+			wantErr: "invalid id",
+		},
+		{
+			name: "id too long",
+			data: buildZip(t, map[string]string{
+				manifestName:    manifestJSON(t, FormatVersion, Entry{ID: strings.Repeat("a", 37), Name: "x", File: "scripts/x.txt"}),
+				"scripts/x.txt": "echo",
+			}),
+			wantErr: "invalid id",
+		},
+		{
+			name: "duplicate id",
+			data: buildZip(t, map[string]string{
+				manifestName:            manifestJSON(t, FormatVersion, valid, valid),
+				"scripts/deploy_01.txt": "echo deploy",
+			}),
+			wantErr: "duplicate script id",
+		},
+		{
+			name: "missing content",
+			data: buildZip(t, map[string]string{
+				manifestName: manifestJSON(t, FormatVersion, valid),
+			}),
+			wantErr: "is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Read(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Read() error = %v, want %q", err, tt.wantErr)
+				}
+				if b != nil {
+					t.Fatalf("Read() returned a bundle together with an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(b.Scripts) != 2 || b.Contents["deploy_01"] != "echo deploy" || b.Contents["backup-02"] != "echo backup" {
+				t.Fatalf("Read() = %+v", b)
+			}
+		})
+	}
+}
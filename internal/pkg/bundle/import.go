@@ -0,0 +1,351 @@
+package bundle
+
+import (
+	"fmt"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/executor"
+	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/gitstore"
+	"hook-panel/internal/pkg/params"
+	"hook-panel/internal/pkg/runner"
+	"hook-panel/internal/pkg/scheduler"
+	"hook-panel/internal/pkg/versions"
+	"hook-panel/internal/pkg/watcher"
+
+	"github.com/google/uuid"
+)
+
+// 导入动作
+const (
+	ActionCreate    = "create"
+	ActionRename    = "rename"
+	ActionOverwrite = "overwrite"
+	ActionSkip      = "skip"
+	ActionError     = "error"
+)
+
+// ImportItem 单个脚本的导入结果
+type ImportItem struct {
+	SourceID string `json:"source_id"`          // 脚本包中的 ID
+	ID       string `json:"id,omitempty"`       // 导入后的 ID，跳过时为冲突的已有脚本
+	Name     string `json:"name"`               // 导入后的名称
+	Action   string `json:"action"`             // create / rename / overwrite / skip / error
+	Conflict string `json:"conflict,omitempty"` // 冲突的已有脚本 ID
+	Error    string `json:"error,omitempty"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	DryRun      bool         `json:"dry_run"`
+	Items       []ImportItem `json:"items"`
+	Created     int          `json:"created"`
+	Overwritten int          `json:"overwritten"`
+	Skipped     int          `json:"skipped"`
+	Failed      int          `json:"failed"`
+}
+
+// Imported 获取成功导入（新建或覆盖）的脚本 ID
+func (r *ImportResult) Imported() []string {
+	var ids []string
+	for _, item := range r.Items {
+		if item.Error == "" && (item.Action == ActionCreate || item.Action == ActionRename || item.Action == ActionOverwrite) {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
+// Changed 获取成功新建（包括重命名后新建）和覆盖的脚本 ID，用于审计日志
+func (r *ImportResult) Changed() ([]string, []string) {
+	created, overwritten := []string{}, []string{}
+	for _, item := range r.Items {
+		if item.Error != "" {
+			continue
+		}
+		switch item.Action {
+		case ActionCreate, ActionRename:
+			created = append(created, item.ID)
+		case ActionOverwrite:
+			overwritten = append(overwritten, item.ID)
+		}
+	}
+	return created, overwritten
+}
+
+// Import 按冲突策略导入脚本包，ID 与已有脚本相同（保留 ID 时）或名称相同视为冲突，
+// 链式触发中引用的脚本 ID 会映射为导入后的 ID，与已有脚本形成循环的脚本标记为失败，
+// dry run 时只返回导入计划
+func Import(b *Bundle, req models.BundleImportRequest, author string) (*ImportResult, error) {
+	db := database.GetDB()
+	var existing []models.Script
+	if err := db.Select("id", "name", "managed").Order("created_at ASC").Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to query scripts: %v", err)
+	}
+	ids := make(map[string]bool, len(existing))
+	managed := make(map[string]bool)
+	byName := make(map[string]string, len(existing))
+	names := make(map[string]bool, len(existing))
+	for _, script := range existing {
+		ids[script.ID] = true
+		managed[script.ID] = script.Managed
+		if _, ok := byName[script.Name]; !ok {
+			byName[script.Name] = script.ID
+		}
+		names[script.Name] = true
+	}
+
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = models.ImportSkip
+	}
+
+	// 生成导入计划，记录脚本包 ID 到导入后 ID 的映射
+	result := &ImportResult{DryRun: req.DryRun, Items: make([]ImportItem, len(b.Scripts))}
+	idMap := make(map[string]string, len(b.Scripts))
+	for i, entry := range b.Scripts {
+		item := ImportItem{SourceID: entry.ID, Name: entry.Name}
+		if err := validate(entry); err != nil {
+			item.Action = ActionError
+			item.Error = err.Error()
+			result.Items[i] = item
+			continue
+		}
+
+		if !req.RemapIDs && ids[entry.ID] {
+			item.Conflict = entry.ID
+		} else {
+			item.Conflict = byName[entry.Name]
+		}
+
+		switch {
+		case item.Conflict == "":
+			item.Action = ActionCreate
+			item.ID = newID(entry.ID, req.RemapIDs, ids)
+		case strategy == models.ImportOverwrite && managed[item.Conflict]:
+			item.Action = ActionError
+			item.Error = "script is managed by the configuration file"
+			result.Items[i] = item
+			continue
+		case strategy == models.ImportOverwrite:
+			item.Action = ActionOverwrite
+			item.ID = item.Conflict
+		case strategy == models.ImportRename:
+			item.Action = ActionRename
+			item.ID = newID(entry.ID, req.RemapIDs, ids)
+			item.Name = uniqueName(entry.Name, names)
+		default:
+			item.Action = ActionSkip
+			item.ID = item.Conflict
+		}
+		ids[item.ID] = true
+		names[item.Name] = true
+		idMap[entry.ID] = item.ID
+		result.Items[i] = item
+	}
+
+	chains, err := planChains(b, result, idMap, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Action != ActionError && item.Action != ActionSkip && !req.DryRun {
+			entry := b.Scripts[i]
+			entry.Chain = chains[i]
+			if err := apply(entry, item, b.Contents[entry.ID], author); err != nil {
+				item.Error = err.Error()
+			}
+		}
+
+		switch {
+		case item.Error != "":
+			result.Failed++
+		case item.Action == ActionSkip:
+			result.Skipped++
+		case item.Action == ActionOverwrite:
+			result.Overwritten++
+		default:
+			result.Created++
+		}
+	}
+	return result, nil
+}
+
+// planChains 将待导入脚本的链式触发映射为导入后的 ID，并在导入后的完整关系上检查循环。
+// 形成循环的脚本标记为失败，不再参与后续检查，其他脚本中指向未能新建的脚本的引用会被去掉
+func planChains(b *Bundle, result *ImportResult, idMap map[string]string, ids map[string]bool) ([]models.ScriptChain, error) {
+	graph, err := runner.LoadChainGraph()
+	if err != nil {
+		return nil, err
+	}
+	chains := make([]models.ScriptChain, len(result.Items))
+	previous := make(map[string][]string)
+	for i, item := range result.Items {
+		if item.Action == ActionError || item.Action == ActionSkip {
+			continue
+		}
+		chain := b.Scripts[i].Chain
+		chains[i] = models.ScriptChain{
+			OnSuccess: mapChain(chain.OnSuccess, item.ID, idMap, ids),
+			OnFailure: mapChain(chain.OnFailure, item.ID, idMap, ids),
+			Always:    mapChain(chain.Always, item.ID, idMap, ids),
+		}
+		previous[item.ID] = graph.Edges(item.ID)
+		graph.Set(item.ID, item.Name, chains[i].Targets())
+	}
+
+	dropped := make(map[string]bool)
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Action == ActionError || item.Action == ActionSkip {
+			continue
+		}
+		if err := graph.Cycle(item.ID); err != nil {
+			// 覆盖的脚本保持原有的链式触发，新建的脚本不会存在
+			if item.Action == ActionOverwrite {
+				graph.Set(item.ID, "", previous[item.ID])
+			} else {
+				graph.Remove(item.ID)
+				dropped[item.ID] = true
+			}
+			item.Action = ActionError
+			item.Error = err.Error()
+		}
+	}
+
+	if len(dropped) > 0 {
+		for i := range chains {
+			chains[i] = models.ScriptChain{
+				OnSuccess: withoutScripts(chains[i].OnSuccess, dropped),
+				OnFailure: withoutScripts(chains[i].OnFailure, dropped),
+				Always:    withoutScripts(chains[i].Always, dropped),
+			}
+		}
+	}
+	return chains, nil
+}
+
+// validate 校验脚本包中的脚本配置，与创建脚本时的校验一致
+func validate(entry Entry) error {
+	if entry.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !executor.Supported(entry.Executor) {
+		return fmt.Errorf("unsupported executor %q", entry.Executor)
+	}
+	if err := params.ValidateDefinitions(entry.Parameters); err != nil {
+		return fmt.Errorf("invalid parameters: %v", err)
+	}
+	if err := params.ValidateEnv(entry.Env); err != nil {
+		return fmt.Errorf("invalid environment variables: %v", err)
+	}
+	if err := entry.Retry.Validate(); err != nil {
+		return fmt.Errorf("invalid retry policy: %v", err)
+	}
+	if err := scheduler.ValidateSchedule(entry.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %v", err)
+	}
+	if err := watcher.Validate(entry.Watch); err != nil {
+		return fmt.Errorf("invalid file watch: %v", err)
+	}
+	return nil
+}
+
+// apply 写入单个脚本，覆盖时保留已有脚本的调用统计
+func apply(entry Entry, item *ImportItem, content, author string) error {
+	script := models.Script{
+		ID:          item.ID,
+		Name:        item.Name,
+		Description: entry.Description,
+		Group:       entry.Group,
+		Executor:    entry.Executor,
+		Enabled:     entry.Enabled,
+		Parameters:  entry.Parameters,
+		Env:         entry.Env,
+		Retry:       entry.Retry,
+		Schedule:    entry.Schedule,
+		Chain:       entry.Chain,
+		Watch:       entry.Watch,
+	}
+	script.Schedule.LastFiredAt = nil
+
+	db := database.GetDB()
+	if item.Action == ActionOverwrite {
+		var current models.Script
+		if err := db.First(&current, "id = ?", item.ID).Error; err != nil {
+			return fmt.Errorf("failed to load script: %v", err)
+		}
+		script.CallCount = current.CallCount
+		script.LastCallAt = current.LastCallAt
+		script.CreatedAt = current.CreatedAt
+		if err := db.Save(&script).Error; err != nil {
+			return fmt.Errorf("failed to update script: %v", err)
+		}
+	} else {
+		if err := db.Create(&script).Error; err != nil {
+			return fmt.Errorf("failed to create script: %v", err)
+		}
+		// enabled 字段有默认值，创建时零值会被忽略
+		if !entry.Enabled {
+			if err := db.Model(&script).Update("enabled", false).Error; err != nil {
+				return fmt.Errorf("failed to create script: %v", err)
+			}
+		}
+	}
+
+	if err := file.SaveScriptContent(script.ID, content); err != nil {
+		return err
+	}
+	if _, err := versions.Record(script.ID, content, script.Executor, author, "Import from bundle"); err != nil {
+		return err
+	}
+	return gitstore.Commit(script.ID, author, "Import script "+script.Name)
+}
+
+// newID 获取新建脚本的 ID，保留 ID 且未被占用时沿用脚本包中的 ID
+func newID(sourceID string, remap bool, ids map[string]bool) string {
+	if !remap && !ids[sourceID] {
+		return sourceID
+	}
+	return uuid.New().String()
+}
+
+// uniqueName 生成不与已有脚本重名的名称
+func uniqueName(name string, names map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if !names[candidate] {
+			return candidate
+		}
+	}
+}
+
+// mapChain 将链式触发引用的 ID 映射为导入后的 ID，去掉不存在的脚本和自身
+func mapChain(list models.StringList, selfID string, idMap map[string]string, ids map[string]bool) models.StringList {
+	result := models.StringList{}
+	for _, id := range list {
+		if mapped, ok := idMap[id]; ok {
+			id = mapped
+		} else if !ids[id] {
+			continue
+		}
+		if id != selfID {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// withoutScripts 去掉列表中的指定脚本
+func withoutScripts(list models.StringList, removed map[string]bool) models.StringList {
+	result := models.StringList{}
+	for _, id := range list {
+		if !removed[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
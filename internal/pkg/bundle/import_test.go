@@ -0,0 +1,82 @@
+package bundle
+
+import (
+	"testing"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestImportRejectsChainCycles(t *testing.T) {
+	db := setupTestDB(t)
+	existing := models.Script{ID: "existing", Name: "existing", Executor: "bash", Enabled: true, Retry: models.DefaultRetryPolicy()}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	entry := func(id, name string, next ...string) Entry {
+		return Entry{
+			ID:       id,
+			Name:     name,
+			Executor: "bash",
+			Retry:    models.DefaultRetryPolicy(),
+			Chain:    models.ScriptChain{OnSuccess: next},
+		}
+	}
+	b := &Bundle{Manifest: Manifest{Version: 1, Scripts: []Entry{
+		entry("a", "a", "b"),
+		entry("b", "b", "a"),
+		entry("self", "self", "self"),
+		entry("c", "existing", "d"), // 按名称覆盖已有脚本
+		entry("d", "d", "existing"),
+	}}}
+
+	result, err := Import(b, models.BundleImportRequest{Strategy: models.ImportOverwrite, DryRun: true}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"a":    "chain forms a cycle: a -> b -> a",
+		"b":    "",
+		"self": "", // 自身引用在映射时去掉
+		"c":    "chain forms a cycle: existing -> d -> existing",
+		"d":    "",
+	}
+	for _, item := range result.Items {
+		if item.Error != want[item.SourceID] {
+			t.Errorf("%s: error = %q, want %q", item.SourceID, item.Error, want[item.SourceID])
+		}
+		if (item.Action == ActionError) != (want[item.SourceID] != "") {
+			t.Errorf("%s: action = %s", item.SourceID, item.Action)
+		}
+	}
+	if result.Failed != 2 || result.Created != 3 {
+		t.Errorf("failed = %d, created = %d, want 2 and 3", result.Failed, result.Created)
+	}
+}
+
+// setupTestDB 使用内存数据库替换全局数据库
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接各自独立，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Script{}, &models.SystemConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+	return db
+}
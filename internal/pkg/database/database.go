@@ -54,7 +54,25 @@ func InitDatabase(port string) error {
 	}
 
 	// 自动迁移
-	if err := DB.AutoMigrate(&models.Script{}, &models.WebhookLog{}, &models.SystemConfig{}); err != nil {
+	if err := DB.AutoMigrate(
+		&models.Script{},
+		&models.WebhookLog{},
+		&models.SystemConfig{},
+		&models.ScriptRun{},
+		&models.ScriptRunAttempt{},
+		&models.ScheduledRun{},
+		&models.Workflow{},
+		&models.WorkflowRun{},
+		&models.WorkflowStepRun{},
+		&models.WorkflowWebhookLog{},
+		&models.ScriptVersion{},
+		&models.ScriptTemplate{},
+		&models.User{},
+		&models.Session{},
+		&models.ScriptPermission{},
+		&models.APIToken{},
+		&models.AuditEvent{},
+	); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
@@ -0,0 +1,354 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/sysconfig"
+)
+
+const (
+	// BuildsDir 编译产物缓存目录
+	BuildsDir = "./data/builds"
+	// compileTimeout 编译超时时间，首次编译可能需要下载依赖或预热缓存
+	compileTimeout = 5 * time.Minute
+)
+
+// CompileResult 编译阶段结果
+type CompileResult struct {
+	Success  bool   `json:"success"`
+	Cached   bool   `json:"cached"`
+	Output   string `json:"output"`
+	Duration string `json:"duration"`
+}
+
+// compiler 编译型语言的构建方式
+type compiler struct {
+	// toolchain 返回编译器路径，为空表示未配置
+	toolchain func() string
+	// versionArgs 查询编译器版本的参数，版本参与缓存哈希，升级编译器后重新编译
+	versionArgs []string
+	// sourceName 返回源文件名（Java 需要与 public class 同名）
+	sourceName func(content string) string
+	// buildCmd 生成编译命令
+	buildCmd func(toolchain, source, outDir string) *exec.Cmd
+	// runCmd 生成运行命令
+	runCmd func(content, outDir string) *exec.Cmd
+}
+
+var (
+	// javaClassPattern 匹配 Java 源码中的 public class 名称
+	javaClassPattern = regexp.MustCompile(`public\s+(?:final\s+|abstract\s+)*class\s+([A-Za-z_$][A-Za-z0-9_$]*)`)
+	// javaPackagePattern 匹配 Java 源码的 package 声明
+	javaPackagePattern = regexp.MustCompile(`(?m)^\s*package\s+([A-Za-z_$][A-Za-z0-9_$]*(?:\s*\.\s*[A-Za-z_$][A-Za-z0-9_$]*)*)\s*;`)
+
+	// activeBuilds 正在使用的构建目录，清理旧版本时跳过，最后一个使用者释放后删除
+	activeBuilds   = make(map[string]*activeBuild)
+	activeBuildsMu sync.Mutex
+
+	// toolchainVersions 编译器版本缓存，按路径和修改时间区分
+	toolchainVersions sync.Map
+)
+
+// activeBuild 构建目录的引用数和编译锁
+type activeBuild struct {
+	refs int
+	// mu 同一构建目录的编译互斥，避免并发触发时重复编译
+	mu sync.Mutex
+}
+
+// compilers 支持编译缓存的执行器
+var compilers = map[string]compiler{
+	"go": {
+		toolchain:   func() string { return sysconfig.GetString("toolchain.go", "go") },
+		versionArgs: []string{"version"},
+		sourceName:  func(string) string { return "main.go" },
+		buildCmd: func(toolchain, source, outDir string) *exec.Cmd {
+			return exec.Command(toolchain, "build", "-o", filepath.Join(outDir, binaryName("main")), source)
+		},
+		runCmd: func(_, outDir string) *exec.Cmd {
+			return exec.Command(filepath.Join(outDir, binaryName("main")))
+		},
+	},
+	"java": {
+		toolchain:   func() string { return sysconfig.GetString("toolchain.javac", "javac") },
+		versionArgs: []string{"-version"},
+		sourceName:  func(content string) string { return javaMainClass(content) + ".java" },
+		buildCmd: func(toolchain, source, outDir string) *exec.Cmd {
+			return exec.Command(toolchain, "-d", outDir, source)
+		},
+		runCmd: func(content, outDir string) *exec.Cmd {
+			return exec.Command(sysconfig.GetString("toolchain.java", "java"), "-cp", outDir, javaQualifiedClass(content))
+		},
+	},
+	"rust": {
+		toolchain:   func() string { return sysconfig.GetString("toolchain.rustc", "") },
+		versionArgs: []string{"--version"},
+		sourceName:  func(string) string { return "main.rs" },
+		buildCmd: func(toolchain, source, outDir string) *exec.Cmd {
+			return exec.Command(toolchain, "-O", "-o", filepath.Join(outDir, binaryName("main")), source)
+		},
+		runCmd: func(_, outDir string) *exec.Cmd {
+			return exec.Command(filepath.Join(outDir, binaryName("main")))
+		},
+	},
+	"c": {
+		toolchain:   func() string { return sysconfig.GetString("toolchain.cc", "") },
+		versionArgs: []string{"--version"},
+		sourceName:  func(string) string { return "main.c" },
+		buildCmd: func(toolchain, source, outDir string) *exec.Cmd {
+			return exec.Command(toolchain, "-O2", "-o", filepath.Join(outDir, binaryName("main")), source)
+		},
+		runCmd: func(_, outDir string) *exec.Cmd {
+			return exec.Command(filepath.Join(outDir, binaryName("main")))
+		},
+	},
+}
+
+// isCompiled 判断执行器是否需要编译
+func isCompiled(executor string) bool {
+	_, ok := compilers[executor]
+	return ok
+}
+
+// executeCompiled 编译（或复用缓存）后运行
+func (e *ScriptExecutor) executeCompiled(scriptID, content, executor string, opts ExecuteOptions) (*ExecutionResult, error) {
+	c := compilers[executor]
+
+	toolchain := c.toolchain()
+	if toolchain == "" {
+		return nil, fmt.Errorf("%s toolchain is not configured", executor)
+	}
+
+	// 在检查缓存之前占用构建目录，避免其他版本编译完成后把它清理掉
+	outDir := buildDir(scriptID, content, executor, toolchain+"\x00"+toolchainVersion(toolchain, c.versionArgs))
+	lock := acquireBuild(outDir)
+	defer releaseBuild(outDir)
+
+	compileResult, err := e.build(scriptID, content, outDir, toolchain, c, lock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 编译失败时直接返回编译阶段的结果，不进入运行阶段
+	if !compileResult.Success {
+		return &ExecutionResult{
+			Success:  false,
+			Error:    compileResult.Output,
+			ExitCode: -1,
+			Phase:    PhaseCompile,
+			Compile:  compileResult,
+		}, nil
+	}
+
+	// 使用绝对路径，程序可能在其他工作目录中运行
+	if abs, err := filepath.Abs(outDir); err == nil {
+		outDir = abs
+	}
+	result, err := e.runCommand(c.runCmd(content, outDir), scriptID, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Compile = compileResult
+	return result, nil
+}
+
+// buildDir 返回按内容哈希命名的构建目录
+func buildDir(scriptID, content, executor, toolchain string) string {
+	hash := sha256.Sum256([]byte(executor + "\x00" + toolchain + "\x00" + content))
+	return filepath.Join(BuildsDir, fmt.Sprintf("%s-%s", scriptID, hex.EncodeToString(hash[:8])))
+}
+
+// toolchainVersion 返回编译器的版本输出，编译器无法运行时返回空字符串，由编译阶段报告错误
+func toolchainVersion(toolchain string, args []string) string {
+	path, err := exec.LookPath(toolchain)
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	key := fmt.Sprintf("%s\x00%d", path, info.ModTime().UnixNano())
+	if version, ok := toolchainVersions.Load(key); ok {
+		return version.(string)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = commandEnv()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	version := strings.TrimSpace(string(output))
+	toolchainVersions.Store(key, version)
+	return version
+}
+
+// build 编译脚本到 outDir，已有缓存时直接复用，lock 为构建目录的编译锁
+func (e *ScriptExecutor) build(scriptID, content, outDir, toolchain string, c compiler, lock *sync.Mutex) (*CompileResult, error) {
+	startTime := time.Now()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// 构建目录存在即表示已编译成功
+	if _, err := os.Stat(outDir); err == nil {
+		file.SaveScriptLog(scriptID, "[COMPILE] Using cached build\n")
+		return &CompileResult{
+			Success:  true,
+			Cached:   true,
+			Duration: time.Since(startTime).String(),
+		}, nil
+	}
+
+	if err := os.MkdirAll(BuildsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create builds directory: %v", err)
+	}
+
+	// 先编译到临时目录，成功后再重命名，保证缓存目录总是完整的
+	workDir, err := os.MkdirTemp(BuildsDir, ".build-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	source := filepath.Join(workDir, c.sourceName(content))
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write source file: %v", err)
+	}
+
+	binDir := filepath.Join(workDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create build directory: %v", err)
+	}
+
+	absSource, _ := filepath.Abs(source)
+	absBinDir, _ := filepath.Abs(binDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), compileTimeout)
+	defer cancel()
+	template := c.buildCmd(toolchain, absSource, absBinDir)
+	cmd := exec.CommandContext(ctx, template.Path, template.Args[1:]...)
+	cmd.Dir = workDir
+	cmd.Env = commandEnv()
+
+	file.SaveScriptLog(scriptID, fmt.Sprintf("[COMPILE] %s\n", strings.Join(cmd.Args, " ")))
+	output, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if line != "" {
+			file.SaveScriptLog(scriptID, fmt.Sprintf("[COMPILE] %s\n", line))
+		}
+	}
+
+	result := &CompileResult{
+		Success:  err == nil,
+		Output:   string(output),
+		Duration: time.Since(startTime).String(),
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			result.Output += fmt.Sprintf("\ncompilation timed out after %s", compileTimeout)
+		} else if _, ok := err.(*exec.ExitError); !ok {
+			result.Output += err.Error()
+		}
+		return result, nil
+	}
+
+	if err := os.Rename(binDir, outDir); err != nil {
+		return nil, fmt.Errorf("failed to save build output: %v", err)
+	}
+	// 新版本就位后再清理旧版本的编译产物，正在运行的旧版本留到下次编译时清理
+	if err := removeBuilds(scriptID, outDir); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CleanBuilds 删除脚本的全部编译缓存，正在运行的构建除外
+func CleanBuilds(scriptID string) error {
+	return removeBuilds(scriptID, "")
+}
+
+// removeBuilds 删除脚本除 keep 和正在使用之外的编译产物
+func removeBuilds(scriptID, keep string) error {
+	matches, err := filepath.Glob(filepath.Join(BuildsDir, scriptID+"-*"))
+	if err != nil {
+		return fmt.Errorf("failed to list builds: %v", err)
+	}
+
+	activeBuildsMu.Lock()
+	defer activeBuildsMu.Unlock()
+	for _, match := range matches {
+		if match == keep || activeBuilds[match] != nil {
+			continue
+		}
+		if err := os.RemoveAll(match); err != nil {
+			return fmt.Errorf("failed to remove build %s: %v", match, err)
+		}
+	}
+	return nil
+}
+
+// acquireBuild 标记构建目录正在使用，返回该目录的编译锁
+func acquireBuild(outDir string) *sync.Mutex {
+	activeBuildsMu.Lock()
+	defer activeBuildsMu.Unlock()
+	b := activeBuilds[outDir]
+	if b == nil {
+		b = &activeBuild{}
+		activeBuilds[outDir] = b
+	}
+	b.refs++
+	return &b.mu
+}
+
+// releaseBuild 释放构建目录的占用，没有其他使用者时删除记录
+func releaseBuild(outDir string) {
+	activeBuildsMu.Lock()
+	defer activeBuildsMu.Unlock()
+	if b := activeBuilds[outDir]; b != nil {
+		if b.refs--; b.refs <= 0 {
+			delete(activeBuilds, outDir)
+		}
+	}
+}
+
+// javaMainClass 获取 Java 源码的主类名，没有 public class 时使用 Main
+func javaMainClass(content string) string {
+	if match := javaClassPattern.FindStringSubmatch(content); match != nil {
+		return match[1]
+	}
+	return "Main"
+}
+
+// javaQualifiedClass 返回主类的完整类名，源码声明了 package 时加上包名
+func javaQualifiedClass(content string) string {
+	name := javaMainClass(content)
+	if match := javaPackagePattern.FindStringSubmatch(content); match != nil {
+		return strings.Join(strings.Fields(match[1]), "") + "." + name
+	}
+	return name
+}
+
+// binaryName 根据平台补全可执行文件后缀
+func binaryName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveBuilds(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	const scriptID = "script"
+	current := buildDir(scriptID, "v3", "go", "go")
+	running := buildDir(scriptID, "v2", "go", "go")
+	stale := buildDir(scriptID, "v1", "go", "go")
+	other := buildDir("other", "v1", "go", "go")
+	for _, dir := range []string{current, running, stale, other} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 旧版本仍在运行时不能被新版本的编译清理掉
+	acquireBuild(running)
+	if err := removeBuilds(scriptID, current); err != nil {
+		t.Fatal(err)
+	}
+	for dir, want := range map[string]bool{current: true, running: true, stale: false, other: true} {
+		if _, err := os.Stat(dir); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(dir), err == nil, want)
+		}
+	}
+
+	releaseBuild(running)
+	if err := CleanBuilds(scriptID); err != nil {
+		t.Fatal(err)
+	}
+	for dir, want := range map[string]bool{current: false, running: false, other: true} {
+		if _, err := os.Stat(dir); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(dir), err == nil, want)
+		}
+	}
+	if len(activeBuilds) != 0 {
+		t.Errorf("activeBuilds = %v, want empty", activeBuilds)
+	}
+}
+
+func TestBuildLockReleased(t *testing.T) {
+	first := acquireBuild("dir")
+	second := acquireBuild("dir")
+	if first != second {
+		t.Fatal("builds of the same directory must share one lock")
+	}
+	releaseBuild("dir")
+	if activeBuilds["dir"] == nil {
+		t.Fatal("build released while still in use")
+	}
+	releaseBuild("dir")
+	if len(activeBuilds) != 0 {
+		t.Errorf("activeBuilds = %v, want empty", activeBuilds)
+	}
+}
+
+func TestJavaQualifiedClass(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"public class Hello {}", "Hello"},
+		{"class Hidden {}", "Main"},
+		{"package com.example;\n\npublic class Hello {}", "com.example.Hello"},
+		{"// package ignored;\npackage com . example . app ;\npublic final class App {}", "com.example.app.App"},
+		{"/*\n * package ignored;\n */\npublic class Hello {}", "Hello"},
+	}
+	for _, tt := range tests {
+		if got := javaQualifiedClass(tt.content); got != tt.want {
+			t.Errorf("javaQualifiedClass(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
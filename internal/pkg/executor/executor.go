@@ -8,20 +8,60 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/artifact"
 	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/sysconfig"
+)
+
+// 执行阶段
+const (
+	PhaseCompile = "compile"
+	PhaseRun     = "run"
 )
 
 // ExecutionResult 执行结果
 type ExecutionResult struct {
-	Success   bool   `json:"success"`
-	Output    string `json:"output"`
-	Error     string `json:"error"`
-	ExitCode  int    `json:"exit_code"`
-	Duration  string `json:"duration"`
-	Timestamp string `json:"timestamp"`
+	Success   bool                `json:"success"`
+	Output    string              `json:"output"`
+	Error     string              `json:"error"`
+	ExitCode  int                 `json:"exit_code"`
+	Duration  string              `json:"duration"`
+	Timestamp string              `json:"timestamp"`
+	Phase     string              `json:"phase"`             // 执行结束时所处的阶段
+	Compile   *CompileResult      `json:"compile,omitempty"` // 编译型执行器的编译阶段结果
+	TimedOut  bool                `json:"timed_out"`
+	RunID     string              `json:"run_id"`              // 运行记录 ID
+	Attempts  int                 `json:"attempts"`            // 实际尝试次数
+	Artifacts []artifact.Artifact `json:"artifacts,omitempty"` // 本次运行保存的产物
+}
+
+// interpreters 解释型执行器
+var interpreters = map[string]bool{
+	"bash": true, "sh": true, "python": true, "python3": true, "node": true, "php": true,
+	"ruby": true, "perl": true, "powershell": true, "cmd": true, "auto": true,
+}
+
+// Supported 判断是否为支持的执行器
+func Supported(executor string) bool {
+	return interpreters[executor] || isCompiled(executor)
+}
+
+// ExecuteOptions 单次执行的附加选项
+type ExecuteOptions struct {
+	Env     []string           // 额外的环境变量（KEY=VALUE）
+	Stdin   string             // 写入脚本标准输入的内容
+	Trigger string             // 触发来源，记录到运行记录中
+	Parent  string             // 链式触发时上一个运行的 ID
+	Dir     string             // 工作目录，为空时使用服务的当前目录
+	Version int                // 执行的脚本版本，记录到运行记录中
+	Retry   models.RetryPolicy // 失败重试策略
+	Context context.Context    // 取消后不再等待重试，为空时不可取消（runner 传入服务运行期间的上下文）
 }
 
 // ScriptExecutor 脚本执行器
@@ -40,7 +80,7 @@ func NewScriptExecutor(timeout time.Duration) *ScriptExecutor {
 }
 
 // ExecuteScript 执行脚本
-func (e *ScriptExecutor) ExecuteScript(scriptID, content, executor string) (*ExecutionResult, error) {
+func (e *ScriptExecutor) ExecuteScript(scriptID, content, executor string, opts ExecuteOptions) (*ExecutionResult, error) {
 	startTime := time.Now()
 	timestamp := startTime.Format("2006-01-02 15:04:05")
 
@@ -50,19 +90,84 @@ func (e *ScriptExecutor) ExecuteScript(scriptID, content, executor string) (*Exe
 		return nil, fmt.Errorf("failed to record execution log: %v", err)
 	}
 
-	// 使用指定的执行器类型执行脚本
-	result, err := e.executeByType(scriptID, content, executor)
+	run := startRun(scriptID, startTime, opts)
+	policy := normalizeRetryPolicy(opts.Retry)
+	retryLimit := time.Duration(sysconfig.GetInt("webhook.retry_timeout", 600)) * time.Second
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// 为本次运行准备产物目录，所有尝试共用
+	env := append([]string{"HOOK_RUN_ID=" + run.ID}, opts.Env...)
+	staging, err := artifact.Prepare(run.ID)
 	if err != nil {
-		// Record error log
-		errorLog := fmt.Sprintf("Execution failed: %v\n", err)
-		file.SaveScriptLog(scriptID, errorLog)
-		return nil, err
+		file.SaveScriptLog(scriptID, fmt.Sprintf("Failed to prepare artifacts directory: %v\n", err))
+	} else {
+		env = append(env, "HOOK_ARTIFACTS_DIR="+staging)
+	}
+	opts.Env = env
+
+	var result *ExecutionResult
+	attempt := 1
+	for ; ; attempt++ {
+		if policy.MaxAttempts > 1 {
+			file.SaveScriptLog(scriptID, fmt.Sprintf("--- Attempt %d/%d ---\n", attempt, policy.MaxAttempts))
+		}
+
+		// 使用指定的执行器类型执行脚本
+		attemptStart := time.Now()
+		result, err = e.executeByType(scriptID, content, executor, opts)
+		if err != nil {
+			// Record error log
+			errorLog := fmt.Sprintf("Execution failed: %v\n", err)
+			file.SaveScriptLog(scriptID, errorLog)
+			recordAttempt(run, attempt, &ExecutionResult{ExitCode: -1, Error: err.Error()}, attemptStart)
+			if staging != "" {
+				os.RemoveAll(staging)
+			}
+			finishRun(run, nil, attempt, time.Since(startTime))
+			return nil, err
+		}
+		result.Duration = time.Since(attemptStart).String()
+		recordAttempt(run, attempt, result, attemptStart)
+
+		if result.Success || attempt >= policy.MaxAttempts || !shouldRetry(policy, result) {
+			break
+		}
+
+		// 重试（含等待）的总时长不超过上限
+		delay := retryDelay(policy, attempt)
+		if time.Since(startTime)+delay >= retryLimit {
+			file.SaveScriptLog(scriptID, fmt.Sprintf("Attempt %d failed: %s, retry time limit (%s) reached\n", attempt, formatExecutionResult(result), retryLimit))
+			break
+		}
+		file.SaveScriptLog(scriptID, fmt.Sprintf("Attempt %d failed: %s, retrying in %s\n", attempt, formatExecutionResult(result), delay))
+		if !waitRetry(ctx, delay) {
+			file.SaveScriptLog(scriptID, "Retry cancelled\n")
+			break
+		}
+	}
+
+	// 保存脚本写入的产物
+	if staging != "" {
+		collected, skipped, err := artifact.Collect(run.ID, staging)
+		if err != nil {
+			file.SaveScriptLog(scriptID, fmt.Sprintf("Failed to collect artifacts: %v\n", err))
+		}
+		if len(skipped) > 0 {
+			file.SaveScriptLog(scriptID, fmt.Sprintf("Artifacts skipped (size limit exceeded): %s\n", strings.Join(skipped, ", ")))
+		}
+		result.Artifacts = collected
 	}
 
 	// 计算执行时间
 	duration := time.Since(startTime)
 	result.Duration = duration.String()
 	result.Timestamp = timestamp
+	result.RunID = run.ID
+	result.Attempts = attempt
+	finishRun(run, result, attempt, duration)
 
 	// Record execution result log
 	resultLog := fmt.Sprintf("Execution result: %s\n", formatExecutionResult(result))
@@ -73,7 +178,12 @@ func (e *ScriptExecutor) ExecuteScript(scriptID, content, executor string) (*Exe
 }
 
 // executeByType 根据脚本类型执行
-func (e *ScriptExecutor) executeByType(scriptID, content, executor string) (*ExecutionResult, error) {
+func (e *ScriptExecutor) executeByType(scriptID, content, executor string, opts ExecuteOptions) (*ExecutionResult, error) {
+	// 编译型语言先编译并缓存产物
+	if isCompiled(executor) {
+		return e.executeCompiled(scriptID, content, executor, opts)
+	}
+
 	// 创建临时脚本文件
 	tempFile, err := e.createTempScript(scriptID, content, executor)
 	if err != nil {
@@ -81,6 +191,13 @@ func (e *ScriptExecutor) executeByType(scriptID, content, executor string) (*Exe
 	}
 	defer os.Remove(tempFile) // 清理临时文件
 
+	// Windows 不支持 shebang，auto 类型按解释器映射到对应的执行器类型
+	if executor == "auto" && runtime.GOOS == "windows" {
+		if interpreter, args, ok := parseShebang(content); ok {
+			executor = shebangExecutor(interpreter, args)
+		}
+	}
+
 	// 根据执行器类型选择命令
 	var cmd *exec.Cmd
 	switch executor {
@@ -98,22 +215,19 @@ func (e *ScriptExecutor) executeByType(scriptID, content, executor string) (*Exe
 		cmd = exec.Command("ruby", tempFile)
 	case "perl":
 		cmd = exec.Command("perl", tempFile)
-	case "go":
-		cmd = exec.Command("go", "run", tempFile)
-	case "java":
-		// Java需要先编译再运行，这里简化处理
-		cmd = exec.Command("java", tempFile)
 	case "powershell":
 		cmd = exec.Command("powershell", "-File", tempFile)
 	case "cmd":
 		cmd = exec.Command("cmd", "/C", tempFile)
+	case "auto":
+		cmd = shebangCommand(content, tempFile)
 	default:
 		// 默认使用bash执行
 		cmd = exec.Command("bash", tempFile)
 	}
 
 	// 执行脚本
-	return e.runCommand(cmd, scriptID)
+	return e.runCommand(cmd, scriptID, opts)
 }
 
 // createTempScript 创建临时脚本文件
@@ -124,6 +238,13 @@ func (e *ScriptExecutor) createTempScript(scriptID, content, executor string) (s
 		return "", fmt.Errorf("failed to create temp directory: %v", err)
 	}
 
+	// auto 类型根据 shebang 中的解释器确定扩展名
+	if executor == "auto" {
+		if interpreter, args, ok := parseShebang(content); ok {
+			executor = shebangExecutor(interpreter, args)
+		}
+	}
+
 	// 根据执行器类型确定文件扩展名
 	var ext string
 	switch executor {
@@ -137,10 +258,6 @@ func (e *ScriptExecutor) createTempScript(scriptID, content, executor string) (s
 		ext = ".rb"
 	case "perl":
 		ext = ".pl"
-	case "go":
-		ext = ".go"
-	case "java":
-		ext = ".java"
 	case "powershell":
 		ext = ".ps1"
 	case "cmd":
@@ -149,43 +266,112 @@ func (e *ScriptExecutor) createTempScript(scriptID, content, executor string) (s
 		ext = ".sh"
 	}
 
-	// 创建临时文件
-	tempFile := filepath.Join(tempDir, fmt.Sprintf("%s_%d%s", scriptID, time.Now().Unix(), ext))
+	// 创建临时文件，文件名随机以支持同一脚本并发执行
+	f, err := os.CreateTemp(tempDir, scriptID+"_*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp script: %v", err)
+	}
+	tempFile := f.Name()
 
 	// 写入脚本内容
-	if err := os.WriteFile(tempFile, []byte(content), 0755); err != nil {
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFile)
+		return "", fmt.Errorf("failed to write temp script: %v", err)
+	}
+	if err := os.Chmod(tempFile, 0755); err != nil {
+		os.Remove(tempFile)
 		return "", fmt.Errorf("failed to write temp script: %v", err)
 	}
 
+	// 使用绝对路径，脚本可能在其他工作目录中运行
+	if abs, err := filepath.Abs(tempFile); err == nil {
+		tempFile = abs
+	}
 	return tempFile, nil
 }
 
+// parseShebang 解析脚本首行的 shebang，返回解释器及其参数
+func parseShebang(content string) (string, []string, bool) {
+	firstLine, _, _ := strings.Cut(content, "\n")
+	firstLine = strings.TrimSpace(strings.TrimPrefix(firstLine, "\ufeff"))
+	if !strings.HasPrefix(firstLine, "#!") {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	return fields[0], fields[1:], true
+}
+
+// shebangCommand 构造 auto 类型的执行命令。有 shebang 时直接执行临时文件（已设置可执行权限），
+// 由内核按 shebang 启动解释器，"#!/usr/bin/env -S python3 -u" 这类写法因此保持原有语义；没有 shebang 时回退到 bash。
+//
+// 内核只把解释器之后的内容作为一个参数传递，"#!/bin/bash -euo pipefail" 直接执行时会被 bash 拒绝，
+// 因此解释器不是 env 且带有多个参数时，改为按空白拆分参数后调用解释器
+func shebangCommand(content, tempFile string) *exec.Cmd {
+	interpreter, args, ok := parseShebang(content)
+	switch {
+	case !ok:
+		return exec.Command("bash", tempFile)
+	case filepath.Base(interpreter) != "env" && len(args) > 1:
+		return exec.Command(interpreter, append(args, tempFile)...)
+	default:
+		return exec.Command(tempFile)
+	}
+}
+
+// shebangExecutor 将 shebang 中的解释器映射为执行器类型，用于确定临时文件扩展名和 Windows 上的执行命令
+func shebangExecutor(interpreter string, args []string) string {
+	name := filepath.Base(interpreter)
+	if name == "env" {
+		// 跳过 env 自身的选项，例如 "#!/usr/bin/env -S python3 -u"
+		name = ""
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				name = filepath.Base(arg)
+				break
+			}
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(name, "python"):
+		return "python"
+	case strings.HasPrefix(name, "node"):
+		return "node"
+	case strings.HasPrefix(name, "php"):
+		return "php"
+	case strings.HasPrefix(name, "ruby"):
+		return "ruby"
+	case strings.HasPrefix(name, "perl"):
+		return "perl"
+	case name == "pwsh" || name == "powershell":
+		return "powershell"
+	default:
+		return "bash"
+	}
+}
+
 // runCommand 运行命令并捕获输出
-func (e *ScriptExecutor) runCommand(cmd *exec.Cmd, scriptID string) (*ExecutionResult, error) {
+func (e *ScriptExecutor) runCommand(cmd *exec.Cmd, scriptID string, opts ExecuteOptions) (*ExecutionResult, error) {
 	// 创建上下文用于超时控制
 	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
 	defer cancel()
 	cmd = exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
+	cmd.Env = append(commandEnv(), opts.Env...)
+	cmd.Dir = opts.Dir
 
-	// 继承当前进程的环境变量
-	cmd.Env = os.Environ()
-
-	// 确保HOME环境变量被设置
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = os.Getenv("HOME")
-		if homeDir == "" {
-			homeDir = "/root" // 默认fallback
-		}
+	// 写入标准输入
+	if opts.Stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.Stdin)
 	}
 
-	// 添加必要的环境变量
-	cmd.Env = append(cmd.Env,
-		"HOME="+homeDir,         // 确保HOME变量存在
-		"GIT_TERMINAL_PROMPT=0", // 禁止Git提示输入凭据
-		"GIT_ASKPASS=true",      // 设置空的askpass程序
-	)
-
 	// 创建管道捕获输出
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -205,14 +391,27 @@ func (e *ScriptExecutor) runCommand(cmd *exec.Cmd, scriptID string) (*ExecutionR
 	// 实时读取输出并记录日志
 	var outputBuilder, errorBuilder strings.Builder
 
+	var wg sync.WaitGroup
+	wg.Add(2)
+
 	// 启动goroutine读取stdout
-	go e.readAndLog(stdout, &outputBuilder, scriptID, "STDOUT")
+	go func() {
+		defer wg.Done()
+		e.readAndLog(stdout, &outputBuilder, scriptID, "STDOUT")
+	}()
 
 	// 启动goroutine读取stderr
-	go e.readAndLog(stderr, &errorBuilder, scriptID, "STDERR")
+	go func() {
+		defer wg.Done()
+		e.readAndLog(stderr, &errorBuilder, scriptID, "STDERR")
+	}()
+
+	// 必须先读完管道再调用 Wait，否则 Wait 关闭管道会丢失尾部输出
+	wg.Wait()
 
 	// 等待命令完成
 	err = cmd.Wait()
+	timedOut := ctx.Err() == context.DeadlineExceeded
 
 	// 构建结果
 	result := &ExecutionResult{
@@ -220,6 +419,8 @@ func (e *ScriptExecutor) runCommand(cmd *exec.Cmd, scriptID string) (*ExecutionR
 		Output:   outputBuilder.String(),
 		Error:    errorBuilder.String(),
 		ExitCode: 0,
+		Phase:    PhaseRun,
+		TimedOut: timedOut,
 	}
 
 	if err != nil {
@@ -233,6 +434,28 @@ func (e *ScriptExecutor) runCommand(cmd *exec.Cmd, scriptID string) (*ExecutionR
 	return result, nil
 }
 
+// commandEnv 构建子进程的环境变量
+func commandEnv() []string {
+	// 继承当前进程的环境变量
+	env := os.Environ()
+
+	// 确保HOME环境变量被设置
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = os.Getenv("HOME")
+		if homeDir == "" {
+			homeDir = "/root" // 默认fallback
+		}
+	}
+
+	// 添加必要的环境变量
+	return append(env,
+		"HOME="+homeDir,         // 确保HOME变量存在
+		"GIT_TERMINAL_PROMPT=0", // 禁止Git提示输入凭据
+		"GIT_ASKPASS=true",      // 设置空的askpass程序
+	)
+}
+
 // readAndLog 读取输出流并记录日志
 func (e *ScriptExecutor) readAndLog(reader io.Reader, builder *strings.Builder, scriptID, prefix string) {
 	scanner := bufio.NewScanner(reader)
@@ -251,6 +474,13 @@ func formatExecutionResult(result *ExecutionResult) string {
 	status := "Success"
 	if !result.Success {
 		status = "Failed"
+		if result.Phase == PhaseCompile {
+			status = "Compilation failed"
+		}
+	}
+
+	if result.TimedOut {
+		status = "Timed out"
 	}
 
 	return fmt.Sprintf("%s (exit code: %d, duration: %s)", status, result.ExitCode, result.Duration)
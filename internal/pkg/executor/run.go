@@ -0,0 +1,185 @@
+package executor
+
+import (
+	"context"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxRetryAttempts 单次运行允许的最大尝试次数
+	maxRetryAttempts = 10
+	// maxRecordedOutput 运行记录中保存的输出长度上限
+	maxRecordedOutput = 64 * 1024
+)
+
+// startRun 创建运行记录
+func startRun(scriptID string, startTime time.Time, opts ExecuteOptions) *models.ScriptRun {
+	trigger := opts.Trigger
+	if trigger == "" {
+		trigger = models.TriggerManual
+	}
+
+	run := &models.ScriptRun{
+		ID:            uuid.New().String(),
+		ScriptID:      scriptID,
+		Trigger:       trigger,
+		ParentRunID:   opts.Parent,
+		ScriptVersion: opts.Version,
+		Status:        models.RunStatusRunning,
+		StartedAt:     startTime,
+	}
+
+	if db := database.GetDB(); db != nil {
+		if err := db.Create(run).Error; err != nil {
+			log.Printf("Failed to create run record for %s: %v", scriptID, err)
+		}
+	}
+	return run
+}
+
+// recordAttempt 保存单次尝试的结果
+func recordAttempt(run *models.ScriptRun, attempt int, result *ExecutionResult, startTime time.Time) {
+	db := database.GetDB()
+	if db == nil {
+		return
+	}
+
+	finishedAt := time.Now()
+	record := models.ScriptRunAttempt{
+		RunID:      run.ID,
+		Attempt:    attempt,
+		Success:    result.Success,
+		ExitCode:   result.ExitCode,
+		TimedOut:   result.TimedOut,
+		Phase:      result.Phase,
+		Output:     truncateOutput(result.Output),
+		Error:      truncateOutput(result.Error),
+		Duration:   finishedAt.Sub(startTime).Milliseconds(),
+		StartedAt:  startTime,
+		FinishedAt: finishedAt,
+	}
+	if err := db.Create(&record).Error; err != nil {
+		log.Printf("Failed to record attempt %d of run %s: %v", attempt, run.ID, err)
+	}
+}
+
+// finishRun 更新运行记录的最终状态，result 为空表示执行器自身出错
+func finishRun(run *models.ScriptRun, result *ExecutionResult, attempts int, duration time.Duration) {
+	db := database.GetDB()
+	if db == nil {
+		return
+	}
+
+	status := models.RunStatusFailed
+	exitCode := -1
+	artifactCount := 0
+	var artifactSize int64
+	if result != nil {
+		exitCode = result.ExitCode
+		if result.Success {
+			status = models.RunStatusSuccess
+		}
+		for _, a := range result.Artifacts {
+			artifactCount++
+			artifactSize += a.Size
+		}
+	}
+
+	now := time.Now()
+	if err := db.Model(run).Updates(map[string]interface{}{
+		"status":         status,
+		"attempts":       attempts,
+		"exit_code":      exitCode,
+		"duration":       duration.Milliseconds(),
+		"finished_at":    now,
+		"artifact_count": artifactCount,
+		"artifact_size":  artifactSize,
+	}).Error; err != nil {
+		log.Printf("Failed to update run record %s: %v", run.ID, err)
+	}
+}
+
+// normalizeRetryPolicy 补全并限制重试策略
+func normalizeRetryPolicy(policy models.RetryPolicy) models.RetryPolicy {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.MaxAttempts > maxRetryAttempts {
+		policy.MaxAttempts = maxRetryAttempts
+	}
+	if policy.Delay < 0 {
+		policy.Delay = 0
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 300
+	}
+	return policy
+}
+
+// shouldRetry 判断失败的尝试是否需要重试
+func shouldRetry(policy models.RetryPolicy, result *ExecutionResult) bool {
+	// 编译失败重试也不会成功
+	if result.Phase == PhaseCompile {
+		return false
+	}
+	if result.TimedOut {
+		return policy.OnTimeout
+	}
+	if len(policy.ExitCodes) == 0 {
+		return true
+	}
+	return policy.ExitCodes.Contains(result.ExitCode)
+}
+
+// retryDelay 计算第 attempt 次尝试失败后的等待时间
+func retryDelay(policy models.RetryPolicy, attempt int) time.Duration {
+	delay := time.Duration(policy.Delay) * time.Second
+	if policy.Backoff == models.BackoffExponential {
+		for i := 1; i < attempt; i++ {
+			delay *= 2
+			if delay >= time.Duration(policy.MaxDelay)*time.Second {
+				break
+			}
+		}
+		if limit := time.Duration(policy.MaxDelay) * time.Second; delay > limit {
+			delay = limit
+		}
+	}
+	return delay
+}
+
+// waitRetry 等待下一次重试，ctx 取消时返回 false
+func waitRetry(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// truncateOutput 截断过长的输出，避免运行记录过大
+func truncateOutput(output string) string {
+	return Tail(output, maxRecordedOutput)
+}
+
+// Tail 保留末尾不超过 limit 字节的内容，起点后移到完整字符的开头，避免截断多字节的 UTF-8 字符
+func Tail(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+	start := len(output) - limit
+	for start < len(output) && !utf8.RuneStart(output[start]) {
+		start++
+	}
+	return output[start:]
+}
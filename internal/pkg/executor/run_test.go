@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"hook-panel/internal/models"
+)
+
+func TestRetryDelay(t *testing.T) {
+	fixed := models.RetryPolicy{Backoff: models.BackoffFixed, Delay: 5, MaxDelay: 300}
+	exponential := models.RetryPolicy{Backoff: models.BackoffExponential, Delay: 5, MaxDelay: 30}
+
+	tests := []struct {
+		name    string
+		policy  models.RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"fixed first", fixed, 1, 5 * time.Second},
+		{"fixed later", fixed, 6, 5 * time.Second},
+		{"fixed ignores max delay", models.RetryPolicy{Backoff: models.BackoffFixed, Delay: 60, MaxDelay: 10}, 3, 60 * time.Second},
+		{"exponential first", exponential, 1, 5 * time.Second},
+		{"exponential doubles", exponential, 2, 10 * time.Second},
+		{"exponential third", exponential, 3, 20 * time.Second},
+		{"exponential capped", exponential, 4, 30 * time.Second},
+		{"exponential stays capped", exponential, 10, 30 * time.Second},
+		{"exponential delay above cap", models.RetryPolicy{Backoff: models.BackoffExponential, Delay: 60, MaxDelay: 30}, 1, 30 * time.Second},
+		{"no delay", models.RetryPolicy{Backoff: models.BackoffExponential, MaxDelay: 30}, 5, 0},
+		{"normalized default cap", normalizeRetryPolicy(models.RetryPolicy{Backoff: models.BackoffExponential, Delay: 100}), 5, 300 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.policy, tt.attempt); got != tt.want {
+				t.Errorf("retryDelay(attempt %d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitRetry(t *testing.T) {
+	if !waitRetry(context.Background(), time.Millisecond) {
+		t.Error("waitRetry should return true after the delay")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	if waitRetry(ctx, time.Minute) {
+		t.Error("waitRetry should return false when cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waitRetry returned after %v, want prompt return on cancel", elapsed)
+	}
+}
+
+func TestTail(t *testing.T) {
+	tests := []struct {
+		output string
+		limit  int
+		want   string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "def"},
+		{"日志输出", 6, "输出"}, // 正好落在字符边界
+		{"日志输出", 7, "输出"}, // 起点落在“志”的中间，后移到“输”
+		{"日志输出", 8, "输出"},
+		{"日志输出", 9, "志输出"},
+		{"a✓b", 3, "b"},
+		{"✓", 2, ""},
+	}
+	for _, tt := range tests {
+		got := Tail(tt.output, tt.limit)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("Tail(%q, %d) = %q, want %q", tt.output, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestShebangCommand(t *testing.T) {
+	tempFile := "/tmp/script.sh"
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"echo hi\n", []string{"bash", tempFile}},
+		{"#!/bin/sh\necho hi\n", []string{tempFile}},
+		{"#!/bin/bash -eu\necho hi\n", []string{tempFile}},
+		{"#!/usr/bin/env -S python3 -u\nprint(1)\n", []string{tempFile}},
+		{"#!/usr/bin/env -S bash -euo pipefail\necho hi\n", []string{tempFile}},
+		{"#!/bin/bash -euo pipefail\necho hi\n", []string{"/bin/bash", "-euo", "pipefail", tempFile}},
+	}
+	for _, tt := range tests {
+		got := shebangCommand(tt.content, tempFile).Args
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("shebangCommand(%q) args = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestShebangCommandEnvSplit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shebang is not supported on Windows")
+	}
+	if err := exec.Command("/usr/bin/env", "-S", "true").Run(); err != nil {
+		t.Skip("env -S is not supported")
+	}
+
+	// env -S 拆分后 sh 收到 -e，第一条命令失败即退出
+	tempFile := filepath.Join(t.TempDir(), "script.sh")
+	content := "#!/usr/bin/env -S sh -e\nfalse\necho unreachable\n"
+	if err := os.WriteFile(tempFile, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	output, err := shebangCommand(content, tempFile).CombinedOutput()
+	if err == nil || strings.Contains(string(output), "unreachable") {
+		t.Errorf("script ran past a failing command: err = %v, output = %q", err, output)
+	}
+}
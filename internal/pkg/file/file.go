@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 )
 
 const (
@@ -11,15 +12,36 @@ const (
 	LogsDir    = "./data/logs"
 )
 
+// scriptIDPattern 外部指定的脚本 ID 需要能直接用在 Webhook 地址和文件名中
+var scriptIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,36}$`)
+
+// ValidScriptID 检查配置文件、导入包等外部来源指定的脚本 ID 是否合法
+func ValidScriptID(id string) bool {
+	return scriptIDPattern.MatchString(id)
+}
+
+// scriptsDir 当前使用的脚本内容目录，启用 Git 存储时指向仓库中的目录
+var scriptsDir = ScriptsDir
+
+// SetScriptsDir 设置脚本内容目录
+func SetScriptsDir(dir string) {
+	scriptsDir = dir
+}
+
+// ScriptPath 获取脚本内容文件的路径
+func ScriptPath(scriptID string) string {
+	return filepath.Join(scriptsDir, scriptID+".txt")
+}
+
 // SaveScriptContent 保存脚本内容到文件
 func SaveScriptContent(scriptID, content string) error {
 	// 确保目录存在
-	if err := os.MkdirAll(ScriptsDir, 0755); err != nil {
+	if err := os.MkdirAll(scriptsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create scripts directory: %v", err)
 	}
 
 	// 脚本文件路径
-	filePath := filepath.Join(ScriptsDir, scriptID+".txt")
+	filePath := ScriptPath(scriptID)
 
 	// 写入文件
 	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
@@ -31,7 +53,7 @@ func SaveScriptContent(scriptID, content string) error {
 
 // ReadScriptContent 读取脚本内容
 func ReadScriptContent(scriptID string) (string, error) {
-	filePath := filepath.Join(ScriptsDir, scriptID+".txt")
+	filePath := ScriptPath(scriptID)
 
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -49,7 +71,7 @@ func ReadScriptContent(scriptID string) (string, error) {
 
 // DeleteScriptContent 删除脚本内容文件
 func DeleteScriptContent(scriptID string) error {
-	filePath := filepath.Join(ScriptsDir, scriptID+".txt")
+	filePath := ScriptPath(scriptID)
 
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
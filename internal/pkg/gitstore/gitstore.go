@@ -0,0 +1,319 @@
+package gitstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/sysconfig"
+	"hook-panel/internal/pkg/versions"
+)
+
+const (
+	// ModeGit 脚本内容保存在 Git 仓库中
+	ModeGit = "git"
+	// scriptsSubdir 仓库中保存脚本内容的目录
+	scriptsSubdir = "scripts"
+	// remoteName 拉取使用的远程仓库名称
+	remoteName = "origin"
+	// committerName 提交者身份，作者使用请求中的操作者
+	committerName  = "Hook Panel"
+	committerEmail = "hook-panel@localhost"
+)
+
+var (
+	// ErrDisabled 未启用 Git 存储
+	ErrDisabled = errors.New("git storage is not enabled")
+	// ErrNoRemote 未配置远程仓库
+	ErrNoRemote = errors.New("git remote is not configured")
+)
+
+var (
+	// repoDir 仓库目录，为空表示未启用
+	repoDir string
+	// mutex 串行执行 Git 操作
+	mutex sync.Mutex
+)
+
+// PullResult 拉取结果
+type PullResult struct {
+	Previous  string   `json:"previous"`  // 拉取前的提交
+	Head      string   `json:"head"`      // 拉取后的提交
+	Updated   []string `json:"updated"`   // 内容已更新的脚本
+	Removed   []string `json:"removed"`   // 远程删除了内容文件的脚本
+	Unknown   []string `json:"unknown"`   // 仓库中存在但系统中没有对应脚本的文件
+	Conflicts []string `json:"conflicts"` // 存在冲突的文件，有冲突时不会做任何修改
+}
+
+// Init 按系统配置初始化 Git 存储，仓库不存在时克隆或新建，并导入已有脚本
+func Init() error {
+	if sysconfig.GetString("storage.mode", "file") != ModeGit {
+		return nil
+	}
+
+	dir, err := filepath.Abs(sysconfig.GetString("storage.git_path", "./data/repo"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve git repository path: %v", err)
+	}
+	branch := sysconfig.GetString("storage.git_branch", "main")
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create git repository: %v", err)
+		}
+		if _, err := git(dir, "init", "--initial-branch", branch); err != nil {
+			return err
+		}
+
+		// 配置了远程仓库时先同步远程内容，远程分支尚不存在时从空仓库开始
+		if remote := sysconfig.GetString("storage.git_remote", ""); remote != "" {
+			if _, err := git(dir, "remote", "add", remoteName, remote); err != nil {
+				return err
+			}
+			if _, err := git(dir, "fetch", remoteName, branch); err != nil {
+				log.Printf("Failed to fetch %s from git remote, starting with an empty repository: %v", branch, err)
+			} else if _, err := git(dir, "merge", "FETCH_HEAD"); err != nil {
+				return err
+			}
+		}
+	}
+
+	scripts := filepath.Join(dir, scriptsSubdir)
+	if err := os.MkdirAll(scripts, 0755); err != nil {
+		return fmt.Errorf("failed to create scripts directory: %v", err)
+	}
+	repoDir = dir
+
+	// 导入文件存储模式下保存的脚本
+	imported, err := importScripts(scripts)
+	if err != nil {
+		return err
+	}
+	if imported > 0 {
+		if err := commit("system", fmt.Sprintf("Import %d existing scripts", imported), scriptsSubdir); err != nil {
+			return err
+		}
+	}
+
+	file.SetScriptsDir(scripts)
+	log.Printf("🌿 Git storage enabled at %s (branch %s)", dir, branch)
+	return nil
+}
+
+// Enabled 是否启用了 Git 存储
+func Enabled() bool {
+	return repoDir != ""
+}
+
+// Commit 提交脚本内容的变更（包括删除），未启用 Git 存储时不做任何操作
+func Commit(scriptID, author, message string) error {
+	if !Enabled() {
+		return nil
+	}
+	return commit(author, message, filepath.ToSlash(filepath.Join(scriptsSubdir, scriptID+".txt")))
+}
+
+// Pull 从配置的远程仓库拉取并合并，有冲突时放弃合并并返回冲突文件
+func Pull() (*PullResult, error) {
+	if !Enabled() {
+		return nil, ErrDisabled
+	}
+	remote := sysconfig.GetString("storage.git_remote", "")
+	if remote == "" {
+		return nil, ErrNoRemote
+	}
+	branch := sysconfig.GetString("storage.git_branch", "main")
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// 远程地址以系统配置为准
+	if _, err := git(repoDir, "remote", "get-url", remoteName); err != nil {
+		if _, err := git(repoDir, "remote", "add", remoteName, remote); err != nil {
+			return nil, err
+		}
+	} else if _, err := git(repoDir, "remote", "set-url", remoteName, remote); err != nil {
+		return nil, err
+	}
+	if _, err := git(repoDir, "fetch", remoteName, branch); err != nil {
+		return nil, err
+	}
+
+	oldHead, _ := git(repoDir, "rev-parse", "--verify", "--quiet", "HEAD")
+	result := &PullResult{Previous: oldHead}
+
+	if _, err := git(repoDir, "merge", "--no-edit", "FETCH_HEAD"); err != nil {
+		conflicts, _ := git(repoDir, "diff", "--name-only", "--diff-filter=U")
+		result.Conflicts = lines(conflicts)
+		git(repoDir, "merge", "--abort")
+		if len(result.Conflicts) == 0 {
+			return nil, err
+		}
+		result.Head = oldHead
+		return result, nil
+	}
+
+	head, err := git(repoDir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	result.Head = head
+	if head == oldHead {
+		return result, nil
+	}
+
+	// 找出本次合并改动的脚本文件
+	var changed string
+	if oldHead == "" {
+		changed, err = git(repoDir, "ls-files", "--", scriptsSubdir)
+	} else {
+		changed, err = git(repoDir, "diff", "--name-only", "--no-renames", oldHead, head, "--", scriptsSubdir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	db := database.GetDB()
+	message := fmt.Sprintf("Pull from %s (%s)", remote, branch)
+	for _, path := range lines(changed) {
+		scriptID := strings.TrimSuffix(filepath.Base(path), ".txt")
+		if filepath.Ext(path) != ".txt" {
+			continue
+		}
+
+		var script models.Script
+		if err := db.First(&script, "id = ?", scriptID).Error; err != nil {
+			result.Unknown = append(result.Unknown, path)
+			continue
+		}
+		if _, err := os.Stat(file.ScriptPath(scriptID)); os.IsNotExist(err) {
+			result.Removed = append(result.Removed, scriptID)
+		} else {
+			result.Updated = append(result.Updated, scriptID)
+		}
+
+		content, err := file.ReadScriptContent(scriptID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := versions.Record(scriptID, content, script.Executor, "git", message); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// commit 暂存指定路径并提交，没有变更时不生成提交
+func commit(author, message, path string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, err := git(repoDir, "add", "--all", "--", path); err != nil {
+		return err
+	}
+	if _, err := git(repoDir, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	if message == "" {
+		message = "Update " + path
+	}
+	_, err := git(repoDir, "commit", "--author", authorLine(author), "-m", message)
+	return err
+}
+
+// authorReplacer 去掉作者名中会破坏 "Name <email>" 格式的字符
+var authorReplacer = strings.NewReplacer("<", "", ">", "", "\r", " ", "\n", " ", "\x00", "")
+
+// authorLine 生成提交的作者信息，用户名来自外部输入，不能包含尖括号或换行
+func authorLine(author string) string {
+	name := strings.Join(strings.Fields(authorReplacer.Replace(author)), " ")
+	if name == "" {
+		name = "admin"
+	}
+	return fmt.Sprintf("%s <%s@hook-panel>", name, strings.ReplaceAll(name, " ", "."))
+}
+
+// importScripts 将文件存储目录中尚未进入仓库的脚本复制到仓库
+func importScripts(target string) (int, error) {
+	entries, err := os.ReadDir(file.ScriptsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read scripts directory: %v", err)
+	}
+
+	imported := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".txt" {
+			continue
+		}
+		dest := filepath.Join(target, entry.Name())
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+		if err := copyFile(filepath.Join(file.ScriptsDir, entry.Name()), dest); err != nil {
+			return imported, fmt.Errorf("failed to import script %s: %v", entry.Name(), err)
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// git 在仓库目录中执行 git 命令，返回去掉首尾空白的标准输出
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// 提交和合并不依赖服务器上的 git 全局配置
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+committerName,
+		"GIT_AUTHOR_EMAIL="+committerEmail,
+		"GIT_COMMITTER_NAME="+committerName,
+		"GIT_COMMITTER_EMAIL="+committerEmail,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// lines 按行拆分命令输出
+func lines(output string) []string {
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// copyFile 复制文件
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
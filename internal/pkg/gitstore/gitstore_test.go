@@ -0,0 +1,22 @@
+package gitstore
+
+import "testing"
+
+func TestAuthorLine(t *testing.T) {
+	tests := []struct {
+		author string
+		want   string
+	}{
+		{"", "admin <admin@hook-panel>"},
+		{"alice", "alice <alice@hook-panel>"},
+		{"Alice Smith", "Alice Smith <Alice.Smith@hook-panel>"},
+		{"eve <root@example.com>", "eve root@example.com <eve.root@example.com@hook-panel>"},
+		{"eve\nSigned-off-by: root", "eve Signed-off-by: root <eve.Signed-off-by:.root@hook-panel>"},
+		{"  \r\n<>  ", "admin <admin@hook-panel>"},
+	}
+	for _, tt := range tests {
+		if got := authorLine(tt.author); got != tt.want {
+			t.Errorf("authorLine(%q) = %q, want %q", tt.author, got, tt.want)
+		}
+	}
+}
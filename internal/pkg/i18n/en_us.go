@@ -25,6 +25,64 @@ func getEnglishMessages() map[string]interface{} {
 				"save_content_failed": "Failed to save script content",
 				"load_content_failed": "Failed to load script content",
 				"execute_failed":      "Script execution failed",
+				"invalid_parameters":  "Invalid script parameters: {{0}}",
+				"invalid_retry":       "Invalid retry policy: {{0}}",
+				"invalid_schedule":    "Invalid schedule: {{0}}",
+				"invalid_chain":       "Invalid chain configuration: {{0}}",
+				"invalid_watch":       "Invalid file watch: {{0}}",
+				"invalid_env":         "Invalid environment variables: {{0}}",
+				"managed":             "Script is managed by the configuration file and cannot be modified",
+			},
+			"version": map[string]interface{}{
+				"not_found":  "Version {{0}} not found",
+				"get_failed": "Failed to get script versions",
+			},
+			"run": map[string]interface{}{
+				"not_found":  "Run not found",
+				"get_failed": "Failed to get run records",
+			},
+			"scheduled_run": map[string]interface{}{
+				"not_found":     "Scheduled run not found",
+				"get_failed":    "Failed to get scheduled runs",
+				"create_failed": "Failed to create scheduled run",
+				"invalid_time":  "Invalid run time: {{0}}",
+				"not_pending":   "Scheduled run has already started or been cancelled",
+			},
+			"artifact": map[string]interface{}{
+				"not_found":  "Artifact not found",
+				"get_failed": "Failed to get artifacts",
+			},
+			"workflow": map[string]interface{}{
+				"not_found":             "Workflow not found",
+				"not_found_or_disabled": "Workflow not found or disabled",
+				"disabled":              "Workflow is disabled",
+				"create_failed":         "Failed to create workflow",
+				"update_failed":         "Failed to update workflow",
+				"delete_failed":         "Failed to delete workflow",
+				"get_failed":            "Failed to get workflows",
+				"execute_failed":        "Failed to start workflow",
+				"invalid":               "Invalid workflow: {{0}}",
+			},
+			"storage": map[string]interface{}{
+				"disabled":      "Git storage is not enabled",
+				"no_remote":     "Git remote repository is not configured",
+				"commit_failed": "Failed to commit script to Git: {{0}}",
+				"pull_failed":   "Failed to pull from Git remote: {{0}}",
+				"conflict":      "Pull aborted: {{0}} files conflict with local changes",
+			},
+			"template": map[string]interface{}{
+				"not_found":     "Template not found",
+				"get_failed":    "Failed to get templates",
+				"create_failed": "Failed to save template",
+				"update_failed": "Failed to update template",
+				"delete_failed": "Failed to delete template",
+				"builtin":       "Built-in templates cannot be modified",
+				"invalid":       "Invalid template: {{0}}",
+				"render_failed": "Failed to render template: {{0}}",
+			},
+			"bundle": map[string]interface{}{
+				"invalid":       "Invalid bundle: {{0}}",
+				"import_failed": "Failed to import bundle",
 			},
 			"webhook": map[string]interface{}{
 				"invalid_signature":   "Signature verification failed",
@@ -37,12 +95,64 @@ func getEnglishMessages() map[string]interface{} {
 				"get_domain_failed":   "Failed to get system domain configuration",
 			},
 			"auth": map[string]interface{}{
-				"invalid_token":         "Invalid access token",
-				"missing_token":         "Missing access token",
-				"unauthorized":          "Unauthorized access",
-				"missing_header":        "Missing Authorization header",
-				"invalid_header_format": "Invalid Authorization header format, should be 'Bearer <token>'",
-				"empty_token":           "Token cannot be empty",
+				"invalid_credentials":      "Invalid username or password",
+				"forbidden_role":           "Permission denied: requires the {{0}} role",
+				"token_restricted":         "This API token is limited to specific scripts and cannot access this resource",
+				"weak_password":            "Password must be at least {{0}} characters",
+				"wrong_password":           "Current password is incorrect",
+				"login_failed":             "Login failed",
+				"rotate_key_failed":        "Failed to rotate access key",
+				"external_key":             "The access key is supplied by an environment variable or key file; replace it there and restart the service",
+				"locked_out":               "Too many failed attempts, retry in {{0}} seconds",
+				"no_session":               "Not signed in with a user account",
+				"totp_required":            "Two-factor verification code required",
+				"invalid_totp":             "Invalid verification code",
+				"totp_already_enabled":     "Two-factor authentication is already enabled",
+				"totp_not_enabled":         "Two-factor authentication is not enabled",
+				"totp_not_pending":         "Start two-factor setup first",
+				"totp_enrollment_required": "Two-factor authentication is required, please complete setup first",
+				"invalid_token":            "Invalid access token",
+				"missing_token":            "Missing access token",
+				"unauthorized":             "Unauthorized access",
+				"missing_header":           "Missing Authorization header",
+				"invalid_header_format":    "Invalid Authorization header format, should be 'Bearer <token>'",
+				"empty_token":              "Token cannot be empty",
+			},
+			"lockout": map[string]interface{}{
+				"not_found": "No failures recorded for {{0}}",
+			},
+			"oidc": map[string]interface{}{
+				"disabled":         "Single sign-on is not enabled",
+				"login_failed":     "Single sign-on failed: {{0}}",
+				"invalid_state":    "The login request has expired, please sign in again",
+				"too_many_logins":  "Too many sign-in requests in progress, please try again later",
+				"invalid_redirect": "Redirect must be a path on this site",
+				"username_taken":   "Username {{0}} is already used by a local account",
+				"not_provisioned":  "Account {{0}} has not been provisioned, please contact an administrator",
+				"no_role":          "Account {{0}} has no assigned role",
+				"user_disabled":    "Account is disabled",
+			},
+			"user": map[string]interface{}{
+				"not_found":           "User not found",
+				"get_failed":          "Failed to get users",
+				"create_failed":       "Failed to create user",
+				"update_failed":       "Failed to update user",
+				"delete_failed":       "Failed to delete user",
+				"exists":              "Username {{0}} already exists",
+				"invalid_permissions": "Invalid permissions: {{0}}",
+				"oidc_not_configured": "Single sign-on issuer is not configured",
+				"oidc_linked":         "This single sign-on identity is already linked to user {{0}}",
+			},
+			"token": map[string]interface{}{
+				"not_found":     "API token not found",
+				"get_failed":    "Failed to get API tokens",
+				"create_failed": "Failed to create API token",
+				"update_failed": "Failed to update API token",
+				"delete_failed": "Failed to revoke API token",
+				"invalid":       "Invalid API token settings: {{0}}",
+			},
+			"audit": map[string]interface{}{
+				"get_failed": "Failed to get audit log",
 			},
 			"database": map[string]interface{}{
 				"connection_failed":  "Database connection failed",
@@ -59,6 +169,55 @@ func getEnglishMessages() map[string]interface{} {
 				"updated": "Script updated successfully ✅",
 				"deleted": "Script deleted successfully 🗑️",
 			},
+			"scheduled_run": map[string]interface{}{
+				"created":   "Run scheduled successfully ⏰",
+				"cancelled": "Scheduled run cancelled",
+			},
+			"workflow": map[string]interface{}{
+				"created": "Workflow created successfully 🎉",
+				"updated": "Workflow updated successfully ✅",
+				"deleted": "Workflow deleted successfully 🗑️",
+				"started": "Workflow started",
+			},
+			"version": map[string]interface{}{
+				"restored": "Restored to version {{0}} ✅",
+			},
+			"storage": map[string]interface{}{
+				"pulled": "Pulled from Git remote, {{0}} scripts updated",
+			},
+			"template": map[string]interface{}{
+				"created": "Template saved successfully 🎉",
+				"updated": "Template updated successfully ✅",
+				"deleted": "Template deleted successfully 🗑️",
+			},
+			"bundle": map[string]interface{}{
+				"imported": "Import finished: {{0}} created, {{1}} overwritten, {{2}} skipped, {{3}} failed",
+				"dry_run":  "Dry run: {{0}} to create, {{1}} to overwrite, {{2}} to skip, {{3}} invalid",
+			},
+			"auth": map[string]interface{}{
+				"logged_out":                 "Signed out",
+				"password_changed":           "Password changed successfully ✅",
+				"totp_enabled":               "Two-factor authentication enabled 🔐 Store the recovery codes safely, they are shown only once",
+				"totp_disabled":              "Two-factor authentication disabled",
+				"recovery_codes_regenerated": "Recovery codes regenerated, the old ones no longer work",
+				"key_rotated":                "Access key rotated 🔑 The new key is shown only once; the old key and webhook signatures remain valid until {{0}}",
+				"key_rotated_immediately":    "Access key rotated 🔑 The new key is shown only once; the old key was revoked immediately",
+			},
+			"lockout": map[string]interface{}{
+				"cleared":     "Lockout cleared for {{0}}",
+				"cleared_all": "Cleared {{0}} failure records",
+			},
+			"user": map[string]interface{}{
+				"created":             "User created successfully 🎉",
+				"updated":             "User updated successfully ✅",
+				"deleted":             "User deleted successfully 🗑️",
+				"permissions_updated": "Permissions updated successfully ✅",
+			},
+			"token": map[string]interface{}{
+				"created": "API token created successfully 🎉 It is shown only once, store it safely",
+				"updated": "API token updated successfully ✅",
+				"deleted": "API token revoked 🗑️",
+			},
 			"webhook": map[string]interface{}{
 				"executed": "Script executed successfully",
 			},
@@ -67,8 +226,13 @@ func getEnglishMessages() map[string]interface{} {
 			},
 		},
 		"category": map[string]interface{}{
-			"system":  "System Configuration",
-			"webhook": "Webhook Configuration",
+			"system":    "System Configuration",
+			"webhook":   "Webhook Configuration",
+			"toolchain": "Toolchain Configuration",
+			"artifacts": "Artifact Configuration",
+			"storage":   "Storage Configuration",
+			"auth":      "Authentication",
+			"oidc":      "Single Sign-On",
 		},
 		"config": map[string]interface{}{
 			"system_domain": map[string]interface{}{
@@ -79,10 +243,130 @@ func getEnglishMessages() map[string]interface{} {
 				"label":       "Execution Timeout",
 				"description": "Script execution timeout (seconds)",
 			},
+			"webhook_retry_timeout": map[string]interface{}{
+				"label":       "Retry Time Limit",
+				"description": "Maximum total time (seconds) spent on retries, including waits; no further retries after it is reached",
+			},
 			"system_language": map[string]interface{}{
 				"label":       "Interface Language",
 				"description": "System interface display language",
 			},
+			"artifacts_max_size": map[string]interface{}{
+				"label":       "Artifact Size Limit",
+				"description": "Maximum total size of artifacts kept per run (MB)",
+			},
+			"artifacts_retention": map[string]interface{}{
+				"label":       "Artifact Retention",
+				"description": "Days to keep run artifacts, 0 keeps them forever",
+			},
+			"storage_mode": map[string]interface{}{
+				"label":       "Storage Mode",
+				"description": "Where script content is stored, Git commits every save (takes effect after restart)",
+			},
+			"storage_git_path": map[string]interface{}{
+				"label":       "Git Repository Path",
+				"description": "Local repository used in Git mode, created or cloned on startup",
+			},
+			"storage_git_remote": map[string]interface{}{
+				"label":       "Git Remote",
+				"description": "Remote repository URL used by pull, leave empty to disable pulling",
+			},
+			"storage_git_branch": map[string]interface{}{
+				"label":       "Git Branch",
+				"description": "Branch to clone and pull from",
+			},
+			"toolchain_go": map[string]interface{}{
+				"label":       "Go Compiler",
+				"description": "Path of the go command used to build Go scripts",
+			},
+			"toolchain_javac": map[string]interface{}{
+				"label":       "Java Compiler",
+				"description": "Path of the javac command used to compile Java scripts",
+			},
+			"toolchain_java": map[string]interface{}{
+				"label":       "Java Runtime",
+				"description": "Path of the java command used to run Java scripts",
+			},
+			"toolchain_rustc": map[string]interface{}{
+				"label":       "Rust Compiler",
+				"description": "Path of rustc, Rust scripts are disabled when empty",
+			},
+			"toolchain_cc": map[string]interface{}{
+				"label":       "C Compiler",
+				"description": "Path of the C compiler (e.g. gcc, clang), C scripts are disabled when empty",
+			},
+			"auth_session_ttl": map[string]interface{}{
+				"label":       "Session Lifetime",
+				"description": "How long a user login session stays valid (hours)",
+			},
+			"auth_require_totp": map[string]interface{}{
+				"label":       "Require Two-Factor Authentication",
+				"description": "When on, every account must enroll in two-factor authentication and can only complete setup until it does",
+			},
+			"auth_key_rotation_grace": map[string]interface{}{
+				"label":       "Key Rotation Grace Period",
+				"description": "Hours the old access key and webhook signatures stay valid after rotation",
+			},
+			"auth_lockout_threshold": map[string]interface{}{
+				"label":       "Lockout Threshold",
+				"description": "Failed authentications (access key, login, webhook signature) from one IP before it is temporarily locked out; responses are delayed progressively, 0 disables",
+			},
+			"auth_lockout_minutes": map[string]interface{}{
+				"label":       "Lockout Duration",
+				"description": "How long a lockout lasts (minutes); also the window for counting failures",
+			},
+			"auth_trusted_proxies": map[string]interface{}{
+				"label":       "Trusted Proxies",
+				"description": "Comma separated IPs or CIDRs of reverse proxies whose X-Forwarded-For is honoured; when empty no proxy is trusted and the connection address is used. Takes effect after restart",
+			},
+			"oidc_enabled": map[string]interface{}{
+				"label":       "Enable Single Sign-On",
+				"description": "Sign in with an OpenID Connect identity provider; the callback URL is /api/auth/oidc/callback",
+			},
+			"oidc_issuer": map[string]interface{}{
+				"label":       "Issuer URL",
+				"description": "Identity provider issuer, used to fetch /.well-known/openid-configuration",
+			},
+			"oidc_client_id": map[string]interface{}{
+				"label":       "Client ID",
+				"description": "Client ID registered with the identity provider",
+			},
+			"oidc_client_secret": map[string]interface{}{
+				"label":       "Client Secret",
+				"description": "Leave empty for public clients that only use PKCE",
+			},
+			"oidc_redirect_url": map[string]interface{}{
+				"label":       "Redirect URL",
+				"description": "Defaults to the system domain + /api/auth/oidc/callback",
+			},
+			"oidc_scopes": map[string]interface{}{
+				"label":       "Scopes",
+				"description": "Space separated, openid is always included",
+			},
+			"oidc_username_claim": map[string]interface{}{
+				"label":       "Username Claim",
+				"description": "ID token claim used as the username, falls back to email and then sub",
+			},
+			"oidc_groups_claim": map[string]interface{}{
+				"label":       "Groups Claim",
+				"description": "ID token claim containing the user's groups",
+			},
+			"oidc_role_mapping": map[string]interface{}{
+				"label":       "Role Mapping",
+				"description": "Group to role mapping, e.g. ops=operator,platform=admin; the highest matching role wins and is synced on every login",
+			},
+			"oidc_default_role": map[string]interface{}{
+				"label":       "Default Role",
+				"description": "Role for users without a mapped group; Reject denies login",
+			},
+			"oidc_auto_provision": map[string]interface{}{
+				"label":       "Auto-Provision Accounts",
+				"description": "Create accounts on first login; when off, an administrator must create them beforehand and link them to the single sign-on subject",
+			},
+			"oidc_link_verified_email": map[string]interface{}{
+				"label":       "Link by Verified Email",
+				"description": "On first single sign-on login, link an existing local account whose username equals the email address verified by the identity provider. Local accounts are never linked by username alone",
+			},
 		},
 		"validation": map[string]interface{}{
 			"required":       "Please enter {{0}}",
@@ -25,6 +25,64 @@ func getChineseMessages() map[string]interface{} {
 				"save_content_failed": "保存脚本内容失败",
 				"load_content_failed": "加载脚本内容失败",
 				"execute_failed":      "脚本执行失败",
+				"invalid_parameters":  "脚本参数错误: {{0}}",
+				"invalid_retry":       "重试策略错误: {{0}}",
+				"invalid_schedule":    "定时配置错误: {{0}}",
+				"invalid_chain":       "链式触发配置错误: {{0}}",
+				"invalid_watch":       "文件监听配置错误: {{0}}",
+				"invalid_env":         "环境变量配置错误: {{0}}",
+				"managed":             "脚本由配置文件管理，不能在此修改",
+			},
+			"version": map[string]interface{}{
+				"not_found":  "版本 {{0}} 不存在",
+				"get_failed": "获取脚本版本失败",
+			},
+			"run": map[string]interface{}{
+				"not_found":  "运行记录不存在",
+				"get_failed": "获取运行记录失败",
+			},
+			"scheduled_run": map[string]interface{}{
+				"not_found":     "计划运行不存在",
+				"get_failed":    "获取计划运行失败",
+				"create_failed": "创建计划运行失败",
+				"invalid_time":  "执行时间错误: {{0}}",
+				"not_pending":   "计划运行已开始执行或已取消",
+			},
+			"artifact": map[string]interface{}{
+				"not_found":  "产物文件不存在",
+				"get_failed": "获取运行产物失败",
+			},
+			"workflow": map[string]interface{}{
+				"not_found":             "工作流不存在",
+				"not_found_or_disabled": "工作流不存在或已禁用",
+				"disabled":              "工作流已禁用",
+				"create_failed":         "创建工作流失败",
+				"update_failed":         "更新工作流失败",
+				"delete_failed":         "删除工作流失败",
+				"get_failed":            "获取工作流失败",
+				"execute_failed":        "启动工作流失败",
+				"invalid":               "工作流配置错误: {{0}}",
+			},
+			"storage": map[string]interface{}{
+				"disabled":      "未启用 Git 存储",
+				"no_remote":     "未配置 Git 远程仓库",
+				"commit_failed": "提交脚本到 Git 失败: {{0}}",
+				"pull_failed":   "从 Git 远程仓库拉取失败: {{0}}",
+				"conflict":      "拉取已取消: {{0}} 个文件与本地修改冲突",
+			},
+			"template": map[string]interface{}{
+				"not_found":     "模板不存在",
+				"get_failed":    "获取模板失败",
+				"create_failed": "保存模板失败",
+				"update_failed": "更新模板失败",
+				"delete_failed": "删除模板失败",
+				"builtin":       "内置模板不能修改",
+				"invalid":       "模板无效: {{0}}",
+				"render_failed": "渲染模板失败: {{0}}",
+			},
+			"bundle": map[string]interface{}{
+				"invalid":       "脚本包无效: {{0}}",
+				"import_failed": "导入脚本包失败",
 			},
 			"webhook": map[string]interface{}{
 				"invalid_signature":   "签名验证失败",
@@ -37,12 +95,64 @@ func getChineseMessages() map[string]interface{} {
 				"get_domain_failed":   "获取系统域名配置失败",
 			},
 			"auth": map[string]interface{}{
-				"invalid_token":         "访问令牌无效",
-				"missing_token":         "缺少访问令牌",
-				"unauthorized":          "未授权访问",
-				"missing_header":        "缺少 Authorization header",
-				"invalid_header_format": "Authorization header 格式错误，应为 'Bearer <token>'",
-				"empty_token":           "Token 不能为空",
+				"invalid_credentials":      "用户名或密码错误",
+				"forbidden_role":           "没有权限执行该操作（需要 {{0}} 角色）",
+				"token_restricted":         "该 API 令牌仅限访问指定脚本，不能访问此资源",
+				"weak_password":            "密码至少需要 {{0}} 个字符",
+				"wrong_password":           "当前密码错误",
+				"login_failed":             "登录失败",
+				"rotate_key_failed":        "轮换访问密钥失败",
+				"external_key":             "访问密钥由环境变量或密钥文件提供，请在外部更换后重启服务",
+				"locked_out":               "认证失败次数过多，请在 {{0}} 秒后重试",
+				"no_session":               "当前未使用账号登录",
+				"totp_required":            "请输入两步验证码",
+				"invalid_totp":             "验证码错误",
+				"totp_already_enabled":     "已启用两步验证",
+				"totp_not_enabled":         "未启用两步验证",
+				"totp_not_pending":         "请先开始绑定两步验证",
+				"totp_enrollment_required": "系统要求启用两步验证，请先完成绑定",
+				"invalid_token":            "访问令牌无效",
+				"missing_token":            "缺少访问令牌",
+				"unauthorized":             "未授权访问",
+				"missing_header":           "缺少 Authorization header",
+				"invalid_header_format":    "Authorization header 格式错误，应为 'Bearer <token>'",
+				"empty_token":              "Token 不能为空",
+			},
+			"lockout": map[string]interface{}{
+				"not_found": "{{0}} 没有失败记录",
+			},
+			"oidc": map[string]interface{}{
+				"disabled":         "未启用单点登录",
+				"login_failed":     "单点登录失败: {{0}}",
+				"invalid_state":    "登录请求已过期，请重新登录",
+				"too_many_logins":  "发起中的登录请求过多，请稍后重试",
+				"invalid_redirect": "跳转地址必须是站内路径",
+				"username_taken":   "用户名 {{0}} 已被本地账号使用",
+				"not_provisioned":  "账号 {{0}} 尚未开通，请联系管理员",
+				"no_role":          "账号 {{0}} 没有可用的角色",
+				"user_disabled":    "账号已被禁用",
+			},
+			"user": map[string]interface{}{
+				"not_found":           "用户不存在",
+				"get_failed":          "获取用户失败",
+				"create_failed":       "创建用户失败",
+				"update_failed":       "更新用户失败",
+				"delete_failed":       "删除用户失败",
+				"exists":              "用户名 {{0}} 已存在",
+				"invalid_permissions": "授权配置错误: {{0}}",
+				"oidc_not_configured": "未配置单点登录的身份提供方",
+				"oidc_linked":         "该单点登录身份已关联用户 {{0}}",
+			},
+			"token": map[string]interface{}{
+				"not_found":     "API 令牌不存在",
+				"get_failed":    "获取 API 令牌失败",
+				"create_failed": "创建 API 令牌失败",
+				"update_failed": "更新 API 令牌失败",
+				"delete_failed": "吊销 API 令牌失败",
+				"invalid":       "API 令牌配置错误: {{0}}",
+			},
+			"audit": map[string]interface{}{
+				"get_failed": "获取审计日志失败",
 			},
 			"database": map[string]interface{}{
 				"connection_failed":  "数据库连接失败",
@@ -59,6 +169,55 @@ func getChineseMessages() map[string]interface{} {
 				"updated": "脚本更新成功 ✅",
 				"deleted": "脚本删除成功 🗑️",
 			},
+			"scheduled_run": map[string]interface{}{
+				"created":   "计划运行创建成功 ⏰",
+				"cancelled": "计划运行已取消",
+			},
+			"workflow": map[string]interface{}{
+				"created": "工作流创建成功 🎉",
+				"updated": "工作流更新成功 ✅",
+				"deleted": "工作流删除成功 🗑️",
+				"started": "工作流已开始运行",
+			},
+			"version": map[string]interface{}{
+				"restored": "已恢复到版本 {{0}} ✅",
+			},
+			"storage": map[string]interface{}{
+				"pulled": "已从 Git 远程仓库拉取，更新了 {{0}} 个脚本",
+			},
+			"template": map[string]interface{}{
+				"created": "模板保存成功 🎉",
+				"updated": "模板更新成功 ✅",
+				"deleted": "模板删除成功 🗑️",
+			},
+			"bundle": map[string]interface{}{
+				"imported": "导入完成: 新建 {{0}} 个，覆盖 {{1}} 个，跳过 {{2}} 个，失败 {{3}} 个",
+				"dry_run":  "预演结果: 将新建 {{0}} 个，覆盖 {{1}} 个，跳过 {{2}} 个，无效 {{3}} 个",
+			},
+			"auth": map[string]interface{}{
+				"logged_out":                 "已退出登录",
+				"password_changed":           "密码修改成功 ✅",
+				"totp_enabled":               "两步验证已启用 🔐 请妥善保存恢复码，它们只显示这一次",
+				"totp_disabled":              "两步验证已关闭",
+				"recovery_codes_regenerated": "恢复码已重新生成，旧的恢复码已失效",
+				"key_rotated":                "访问密钥已轮换 🔑 新密钥只显示这一次，旧密钥和旧 webhook 签名在 {{0}} 前仍然有效",
+				"key_rotated_immediately":    "访问密钥已轮换 🔑 新密钥只显示这一次，旧密钥已立即失效",
+			},
+			"lockout": map[string]interface{}{
+				"cleared":     "已解除 {{0}} 的锁定",
+				"cleared_all": "已清除 {{0}} 条失败记录",
+			},
+			"user": map[string]interface{}{
+				"created":             "用户创建成功 🎉",
+				"updated":             "用户更新成功 ✅",
+				"deleted":             "用户删除成功 🗑️",
+				"permissions_updated": "授权已更新 ✅",
+			},
+			"token": map[string]interface{}{
+				"created": "API 令牌创建成功 🎉 令牌只显示这一次，请妥善保存",
+				"updated": "API 令牌更新成功 ✅",
+				"deleted": "API 令牌已吊销 🗑️",
+			},
 			"webhook": map[string]interface{}{
 				"executed": "脚本执行成功",
 			},
@@ -67,8 +226,13 @@ func getChineseMessages() map[string]interface{} {
 			},
 		},
 		"category": map[string]interface{}{
-			"system":  "系统配置",
-			"webhook": "Webhook 配置",
+			"system":    "系统配置",
+			"webhook":   "Webhook 配置",
+			"toolchain": "工具链配置",
+			"artifacts": "产物配置",
+			"storage":   "存储配置",
+			"auth":      "认证配置",
+			"oidc":      "单点登录",
 		},
 		"config": map[string]interface{}{
 			"system_domain": map[string]interface{}{
@@ -79,10 +243,130 @@ func getChineseMessages() map[string]interface{} {
 				"label":       "执行超时时间",
 				"description": "脚本执行超时时间（秒）",
 			},
+			"webhook_retry_timeout": map[string]interface{}{
+				"label":       "重试总时长上限",
+				"description": "失败重试（含等待时间）的总时长上限（秒），超出后不再重试",
+			},
 			"system_language": map[string]interface{}{
 				"label":       "界面语言",
 				"description": "系统界面显示语言",
 			},
+			"artifacts_max_size": map[string]interface{}{
+				"label":       "产物大小上限",
+				"description": "每次运行保留的产物总大小上限（MB）",
+			},
+			"artifacts_retention": map[string]interface{}{
+				"label":       "产物保留天数",
+				"description": "运行产物保留的天数，0 表示永久保留",
+			},
+			"storage_mode": map[string]interface{}{
+				"label":       "存储方式",
+				"description": "脚本内容的存储位置，Git 模式下每次保存都会提交（重启后生效）",
+			},
+			"storage_git_path": map[string]interface{}{
+				"label":       "Git 仓库路径",
+				"description": "Git 模式使用的本地仓库，启动时自动创建或克隆",
+			},
+			"storage_git_remote": map[string]interface{}{
+				"label":       "Git 远程仓库",
+				"description": "拉取使用的远程仓库地址，为空时不能拉取",
+			},
+			"storage_git_branch": map[string]interface{}{
+				"label":       "Git 分支",
+				"description": "克隆和拉取使用的分支",
+			},
+			"toolchain_go": map[string]interface{}{
+				"label":       "Go 编译器",
+				"description": "用于编译 Go 脚本的 go 命令路径",
+			},
+			"toolchain_javac": map[string]interface{}{
+				"label":       "Java 编译器",
+				"description": "用于编译 Java 脚本的 javac 命令路径",
+			},
+			"toolchain_java": map[string]interface{}{
+				"label":       "Java 运行时",
+				"description": "用于运行 Java 脚本的 java 命令路径",
+			},
+			"toolchain_rustc": map[string]interface{}{
+				"label":       "Rust 编译器",
+				"description": "rustc 路径，留空则禁用 Rust 脚本",
+			},
+			"toolchain_cc": map[string]interface{}{
+				"label":       "C 编译器",
+				"description": "C 编译器路径（如 gcc、clang），留空则禁用 C 脚本",
+			},
+			"auth_session_ttl": map[string]interface{}{
+				"label":       "会话有效期",
+				"description": "账号登录后会话的有效时间（小时）",
+			},
+			"auth_require_totp": map[string]interface{}{
+				"label":       "强制两步验证",
+				"description": "开启后所有账号必须绑定两步验证，未绑定的账号登录后只能进行绑定",
+			},
+			"auth_key_rotation_grace": map[string]interface{}{
+				"label":       "密钥轮换宽限期",
+				"description": "轮换访问密钥后旧密钥和旧 webhook 签名继续有效的时间（小时）",
+			},
+			"auth_lockout_threshold": map[string]interface{}{
+				"label":       "锁定阈值",
+				"description": "同一 IP 认证失败（访问密钥、登录、webhook 签名）达到该次数后暂时锁定，每次失败的响应会逐步延迟，0 表示关闭",
+			},
+			"auth_lockout_minutes": map[string]interface{}{
+				"label":       "锁定时长",
+				"description": "锁定持续的时间（分钟），也是失败计数的统计窗口",
+			},
+			"auth_trusted_proxies": map[string]interface{}{
+				"label":       "可信代理",
+				"description": "可信反向代理的 IP 或网段（逗号分隔），只采信它们转发的 X-Forwarded-For，留空时不信任任何代理，直接使用连接的来源 IP。重启后生效",
+			},
+			"oidc_enabled": map[string]interface{}{
+				"label":       "启用单点登录",
+				"description": "使用 OpenID Connect 身份提供方登录，回调地址为 /api/auth/oidc/callback",
+			},
+			"oidc_issuer": map[string]interface{}{
+				"label":       "签发方地址",
+				"description": "身份提供方的 Issuer，用于获取 /.well-known/openid-configuration",
+			},
+			"oidc_client_id": map[string]interface{}{
+				"label":       "客户端 ID",
+				"description": "在身份提供方登记的客户端 ID",
+			},
+			"oidc_client_secret": map[string]interface{}{
+				"label":       "客户端密钥",
+				"description": "公共客户端可留空，仅使用 PKCE",
+			},
+			"oidc_redirect_url": map[string]interface{}{
+				"label":       "回调地址",
+				"description": "留空时使用系统域名 + /api/auth/oidc/callback",
+			},
+			"oidc_scopes": map[string]interface{}{
+				"label":       "授权范围",
+				"description": "以空格分隔，始终包含 openid",
+			},
+			"oidc_username_claim": map[string]interface{}{
+				"label":       "用户名声明",
+				"description": "作为用户名的 ID Token 声明，缺失时依次使用 email 和 sub",
+			},
+			"oidc_groups_claim": map[string]interface{}{
+				"label":       "分组声明",
+				"description": "包含用户分组的 ID Token 声明",
+			},
+			"oidc_role_mapping": map[string]interface{}{
+				"label":       "角色映射",
+				"description": "分组到角色的映射，如 ops=operator,platform=admin，匹配多个时取最高角色，每次登录时同步",
+			},
+			"oidc_default_role": map[string]interface{}{
+				"label":       "默认角色",
+				"description": "没有匹配分组时的角色，选择拒绝时不允许登录",
+			},
+			"oidc_auto_provision": map[string]interface{}{
+				"label":       "自动开通账号",
+				"description": "首次登录时自动创建账号，关闭后只能由管理员预先创建并关联单点登录的 sub",
+			},
+			"oidc_link_verified_email": map[string]interface{}{
+				"label":       "按已验证邮箱关联",
+				"description": "首次单点登录时，关联用户名与身份提供方已验证邮箱相同的本地账号。本地账号不会仅按用户名关联",
+			},
 		},
 		"validation": map[string]interface{}{
 			"required":       "请输入{{0}}",
@@ -0,0 +1,248 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/audit"
+	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/sysconfig"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 认证失败的类型
+const (
+	ScopeAPI     = "api"     // 管理接口的访问密钥
+	ScopeLogin   = "login"   // 用户名密码登录
+	ScopeTOTP    = "totp"    // 登录时的两步验证码
+	ScopeWebhook = "webhook" // webhook 签名
+)
+
+const (
+	defaultThreshold = 10
+	defaultMinutes   = 15
+	// delayStep 每次失败增加的响应延迟，第一次失败不延迟
+	delayStep = 500 * time.Millisecond
+	// maxDelay 响应延迟的上限
+	maxDelay = 5 * time.Second
+	// sweepInterval 清理过期记录的间隔
+	sweepInterval = time.Minute
+)
+
+// entry 单个 IP 某一类认证的失败记录
+type entry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+var (
+	mu sync.Mutex
+	// entries IP -> 认证类型 -> 失败记录。各类认证分别计数，
+	// 某一类认证成功不会清除其他类型的失败次数
+	entries   = make(map[string]map[string]*entry)
+	lastSweep time.Time
+)
+
+// Threshold 触发锁定的连续失败次数，0 表示关闭防护
+func Threshold() int {
+	return sysconfig.GetInt("auth.lockout_threshold", defaultThreshold)
+}
+
+// Duration 锁定时长，也是失败计数的有效窗口：超过该时间没有新的失败则重新计数
+func Duration() time.Duration {
+	minutes := sysconfig.GetInt("auth.lockout_minutes", defaultMinutes)
+	if minutes <= 0 {
+		minutes = defaultMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Check 返回 IP 剩余的锁定时间，未锁定时为 0。任一类认证触发锁定后该 IP 的全部认证都被拒绝
+func Check(ip string) time.Duration {
+	mu.Lock()
+	defer mu.Unlock()
+	var remaining time.Duration
+	for _, e := range entries[ip] {
+		if wait := time.Until(e.lockedUntil); wait > remaining {
+			remaining = wait
+		}
+	}
+	return remaining
+}
+
+// Failure 记录一次失败，返回响应前应延迟的时间，以及本次失败是否触发了锁定
+func Failure(ip, scope string) (time.Duration, bool) {
+	threshold := Threshold()
+	if threshold <= 0 {
+		return 0, false
+	}
+	window := Duration()
+	now := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if now.Sub(lastSweep) > sweepInterval {
+		sweep(now, window)
+		lastSweep = now
+	}
+
+	scopes, ok := entries[ip]
+	if !ok {
+		scopes = make(map[string]*entry)
+		entries[ip] = scopes
+	}
+	e, ok := scopes[scope]
+	if !ok || expired(e, now, window) {
+		e = &entry{}
+		scopes[scope] = e
+	}
+	e.failures++
+	e.lastFailure = now
+
+	locked := false
+	if e.failures >= threshold && now.After(e.lockedUntil) {
+		e.lockedUntil = now.Add(window)
+		locked = true
+	}
+
+	delay := time.Duration(e.failures-1) * delayStep
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay, locked
+}
+
+// List 返回各 IP 每类认证的失败记录，已锁定的排在前面
+func List() []models.Lockout {
+	window := Duration()
+	now := time.Now()
+
+	mu.Lock()
+	sweep(now, window)
+	result := make([]models.Lockout, 0, len(entries))
+	for ip, scopes := range entries {
+		for scope, e := range scopes {
+			item := models.Lockout{
+				IP:            ip,
+				Failures:      e.failures,
+				Scope:         scope,
+				LastFailureAt: e.lastFailure,
+				Locked:        now.Before(e.lockedUntil),
+			}
+			if item.Locked {
+				until := e.lockedUntil
+				item.LockedUntil = &until
+			}
+			result = append(result, item)
+		}
+	}
+	mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Locked != result[j].Locked {
+			return result[i].Locked
+		}
+		return result[i].LastFailureAt.After(result[j].LastFailureAt)
+	})
+	return result
+}
+
+// Clear 清除 IP 的失败记录和锁定，返回是否存在该记录
+func Clear(ip string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, ok := entries[ip]
+	delete(entries, ip)
+	return ok
+}
+
+// Reset 某一类认证成功后清除 IP 该类认证的失败计数，其他类型和已处于锁定中的不受影响
+func Reset(ip, scope string) {
+	mu.Lock()
+	defer mu.Unlock()
+	scopes := entries[ip]
+	if e, ok := scopes[scope]; ok && time.Now().After(e.lockedUntil) {
+		delete(scopes, scope)
+		if len(scopes) == 0 {
+			delete(entries, ip)
+		}
+	}
+}
+
+// ClearAll 清除全部失败记录和锁定，返回清除的数量
+func ClearAll() int {
+	mu.Lock()
+	defer mu.Unlock()
+	count := len(entries)
+	entries = make(map[string]map[string]*entry)
+	return count
+}
+
+// Guard 请求来源已被锁定时写入 429 响应并返回 false
+func Guard(c *gin.Context) bool {
+	wait := Check(c.ClientIP())
+	if wait <= 0 {
+		return true
+	}
+	c.Header("Retry-After", RetryAfter(wait))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": i18n.T(c, "error.auth.locked_out", RetryAfter(wait)),
+	})
+	c.Abort()
+	return false
+}
+
+// Fail 记录请求来源的一次认证失败并按失败次数延迟，触发锁定时写入审计事件。
+// 调用方随后返回 401
+func Fail(c *gin.Context, scope string) {
+	ip := c.ClientIP()
+	delay, locked := Failure(ip, scope)
+	if locked {
+		c.Status(http.StatusUnauthorized)
+		audit.RecordAction(c, "auth.lockout", ip)
+	}
+	if delay > 0 {
+		wait(c.Request.Context(), delay)
+	}
+}
+
+// RetryAfter 将剩余时间转换为 Retry-After 使用的秒数
+func RetryAfter(d time.Duration) string {
+	return fmt.Sprint(int((d + time.Second - 1) / time.Second))
+}
+
+// wait 延迟响应，客户端断开时提前返回
+func wait(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// expired 记录是否已失效：未处于锁定中且最近一次失败已超出窗口
+func expired(e *entry, now time.Time, window time.Duration) bool {
+	return now.After(e.lockedUntil) && now.Sub(e.lastFailure) > window
+}
+
+// sweep 清理失效的记录
+func sweep(now time.Time, window time.Duration) {
+	for ip, scopes := range entries {
+		for scope, e := range scopes {
+			if expired(e, now, window) {
+				delete(scopes, scope)
+			}
+		}
+		if len(scopes) == 0 {
+			delete(entries, ip)
+		}
+	}
+}
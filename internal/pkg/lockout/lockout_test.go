@@ -0,0 +1,116 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFailureDelayAndLockout(t *testing.T) {
+	ClearAll()
+	defer ClearAll()
+
+	const ip = "192.0.2.1"
+	for i := 1; i <= defaultThreshold; i++ {
+		delay, locked := Failure(ip, ScopeLogin)
+		want := time.Duration(i-1) * delayStep
+		if want > maxDelay {
+			want = maxDelay
+		}
+		if delay != want {
+			t.Errorf("failure %d: delay = %v, want %v", i, delay, want)
+		}
+		if locked != (i == defaultThreshold) {
+			t.Errorf("failure %d: locked = %v", i, locked)
+		}
+	}
+	if wait := Check(ip); wait <= 0 || wait > Duration() {
+		t.Fatalf("Check() = %v, want locked for up to %v", wait, Duration())
+	}
+
+	// 锁定期间的成功不解除锁定
+	Reset(ip, ScopeLogin)
+	if Check(ip) <= 0 {
+		t.Fatal("Reset must not clear an active lockout")
+	}
+	if !Clear(ip) || Check(ip) > 0 {
+		t.Fatal("Clear should remove the lockout")
+	}
+}
+
+func TestResetClearsFailures(t *testing.T) {
+	ClearAll()
+	defer ClearAll()
+
+	const ip = "192.0.2.2"
+	for i := 0; i < 3; i++ {
+		Failure(ip, ScopeLogin)
+	}
+	Reset(ip, ScopeLogin)
+	if delay, _ := Failure(ip, ScopeLogin); delay != 0 {
+		t.Errorf("first failure after Reset delayed %v, want 0", delay)
+	}
+	if len(List()) != 1 || List()[0].Failures != 1 {
+		t.Errorf("List() = %+v, want one entry with one failure", List())
+	}
+}
+
+func TestResetKeepsOtherScopes(t *testing.T) {
+	ClearAll()
+	defer ClearAll()
+
+	const ip = "192.0.2.3"
+	for i := 0; i < 3; i++ {
+		Failure(ip, ScopeTOTP)
+		Failure(ip, ScopeWebhook)
+	}
+	Failure(ip, ScopeLogin)
+
+	// 密码正确只清除密码登录的失败次数
+	Reset(ip, ScopeLogin)
+	failures := map[string]int{}
+	for _, item := range List() {
+		failures[item.Scope] = item.Failures
+	}
+	want := map[string]int{ScopeTOTP: 3, ScopeWebhook: 3}
+	if len(failures) != len(want) || failures[ScopeTOTP] != 3 || failures[ScopeWebhook] != 3 {
+		t.Errorf("failures after Reset = %v, want %v", failures, want)
+	}
+	if delay, _ := Failure(ip, ScopeTOTP); delay != 3*delayStep {
+		t.Errorf("fourth totp failure delayed %v, want %v", delay, 3*delayStep)
+	}
+}
+
+func TestLockoutAppliesToAllScopes(t *testing.T) {
+	ClearAll()
+	defer ClearAll()
+
+	const ip = "192.0.2.4"
+	for i := 0; i < defaultThreshold; i++ {
+		Failure(ip, ScopeWebhook)
+	}
+	if Check(ip) <= 0 {
+		t.Fatal("webhook failures should lock the address")
+	}
+	// 其他类型的成功不解除锁定
+	Reset(ip, ScopeLogin)
+	Reset(ip, ScopeWebhook)
+	if Check(ip) <= 0 {
+		t.Fatal("Reset must not clear an active lockout")
+	}
+	if !Clear(ip) || Check(ip) > 0 || len(List()) != 0 {
+		t.Fatal("Clear should remove every scope of the address")
+	}
+}
+
+func TestRetryAfterRoundsUp(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Second:                    "1",
+		1500 * time.Millisecond:        "2",
+		time.Minute - time.Millisecond: "60",
+	}
+	for d, want := range tests {
+		if got := RetryAfter(d); got != want {
+			t.Errorf("RetryAfter(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
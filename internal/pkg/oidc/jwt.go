@@ -0,0 +1,242 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // 注册 SHA-256 供 crypto.SHA256 使用
+	_ "crypto/sha512" // 注册 SHA-384 / SHA-512
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockSkew 校验过期时间时允许的时钟偏差
+const clockSkew = time.Minute
+
+// algorithms 支持的 ID Token 签名算法
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+}
+
+// jwk JWKS 中的单个公钥
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+var (
+	keysMu    sync.Mutex
+	keysCache = make(map[string][]jwk) // jwks_uri -> 公钥
+)
+
+// verifyIDToken 校验 ID Token 的签名、签发方、受众、有效期和 nonce，返回其中的声明
+func verifyIDToken(doc *discovery, cfg *Config, token, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id_token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid id_token header: %v", err)
+	}
+	hash, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported id_token algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token signature encoding")
+	}
+
+	key, err := findKey(doc.JWKSURI, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(key, hash, h.Sum(nil), signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid id_token payload: %v", err)
+	}
+
+	if strings.TrimSuffix(claimString(claims, "iss"), "/") != cfg.Issuer {
+		return nil, fmt.Errorf("id_token issuer mismatch")
+	}
+	if !containsString(claimStrings(claims, "aud"), cfg.ClientID) {
+		return nil, fmt.Errorf("id_token audience mismatch")
+	}
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return nil, fmt.Errorf("id_token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("id_token not yet valid")
+	}
+	if subtle.ConstantTimeCompare([]byte(claimString(claims, "nonce")), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("id_token nonce mismatch")
+	}
+	if claimString(claims, "sub") == "" {
+		return nil, fmt.Errorf("id_token has no subject")
+	}
+	return claims, nil
+}
+
+// findKey 按 kid 查找公钥，找不到时重新获取 JWKS 以支持密钥轮换
+func findKey(jwksURI, kid, alg string) (crypto.PublicKey, error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		keys, ok := keysCache[jwksURI]
+		if !ok || attempt > 0 {
+			var set struct {
+				Keys []jwk `json:"keys"`
+			}
+			if err := getJSON(jwksURI, &set); err != nil {
+				return nil, fmt.Errorf("failed to fetch jwks: %v", err)
+			}
+			keys = set.Keys
+			keysCache[jwksURI] = keys
+		}
+
+		for _, k := range keys {
+			if (kid != "" && k.Kid != kid) || (k.Use != "" && k.Use != "sig") {
+				continue
+			}
+			if strings.HasPrefix(alg, "RS") && k.Kty == "RSA" || strings.HasPrefix(alg, "ES") && k.Kty == "EC" {
+				return parseKey(k)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no matching signing key for kid %q", kid)
+}
+
+// parseKey 将 JWK 转换为公钥
+func parseKey(k jwk) (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa key: %v", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa key: %v", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec key: %v", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec key: %v", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifySignature 校验签名，ECDSA 签名为定长的 r || s
+func verifySignature(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid id_token signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid id_token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("invalid id_token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported key type")
+}
+
+// decodeSegment 解码 JWT 中 Base64URL 编码的 JSON 片段
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// claimString 读取字符串声明
+func claimString(claims map[string]interface{}, name string) string {
+	if s, ok := claims[name].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// claimBool 读取布尔声明，部分身份提供方以字符串 "true" 表示
+func claimBool(claims map[string]interface{}, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// claimStrings 读取字符串或字符串数组声明
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var result []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/auth"
+	"hook-panel/internal/pkg/sysconfig"
+)
+
+const (
+	// CallbackPath 授权回调地址，需在身份提供方登记
+	CallbackPath = "/api/auth/oidc/callback"
+	// StateCookie 保存 state 的 Cookie，回调时与地址中的 state 比对，确保回调发生在发起登录的浏览器中
+	StateCookie = "hook_panel_oidc_state"
+	// StateTTL 发起登录到回调的最长时间
+	StateTTL = 10 * time.Minute
+	// maxPending 同时等待回调的登录数上限，发起登录的接口不需要认证
+	maxPending = 1000
+	// discoveryTTL 发现文档的缓存时间
+	discoveryTTL = time.Hour
+	// maxResponseSize 身份提供方响应的大小上限
+	maxResponseSize = 1 << 20
+)
+
+var (
+	// ErrDisabled 未启用单点登录
+	ErrDisabled = errors.New("oidc login is disabled")
+	// ErrInvalidState state 不存在或已过期
+	ErrInvalidState = errors.New("invalid or expired login state")
+	// ErrTooManyLogins 等待回调的登录过多
+	ErrTooManyLogins = errors.New("too many pending logins")
+)
+
+// httpClient 访问身份提供方使用的客户端
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// Config 单点登录配置，来自系统配置中的 oidc.* 项
+type Config struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	Scopes        []string
+	UsernameClaim string
+	GroupsClaim   string
+	RoleMapping   map[string]string // 分组 -> 角色
+	DefaultRole   string            // 没有匹配分组时的角色，none 表示拒绝登录
+	AutoProvision bool
+	// LinkVerifiedEmail 首次登录时是否关联用户名与已验证邮箱相同的本地账号
+	LinkVerifiedEmail bool
+}
+
+// Enabled 是否启用单点登录
+func Enabled() bool {
+	return sysconfig.GetBool("oidc.enabled", false)
+}
+
+// Issuer 配置的身份提供方地址，管理员手动关联账号时使用
+func Issuer() string {
+	return strings.TrimSuffix(sysconfig.GetString("oidc.issuer", ""), "/")
+}
+
+// LoadConfig 读取并校验单点登录配置
+func LoadConfig() (*Config, error) {
+	if !Enabled() {
+		return nil, ErrDisabled
+	}
+
+	cfg := &Config{
+		Issuer:        Issuer(),
+		ClientID:      sysconfig.GetString("oidc.client_id", ""),
+		ClientSecret:  sysconfig.GetString("oidc.client_secret", ""),
+		RedirectURL:   sysconfig.GetString("oidc.redirect_url", ""),
+		Scopes:        strings.Fields(sysconfig.GetString("oidc.scopes", "openid profile email")),
+		UsernameClaim: sysconfig.GetString("oidc.username_claim", "preferred_username"),
+		GroupsClaim:   sysconfig.GetString("oidc.groups_claim", "groups"),
+		DefaultRole:   sysconfig.GetString("oidc.default_role", models.RoleViewer),
+		AutoProvision: sysconfig.GetBool("oidc.auto_provision", true),
+		// 关联已有账号需要管理员明确开启
+		LinkVerifiedEmail: sysconfig.GetBool("oidc.link_verified_email", false),
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, fmt.Errorf("oidc.issuer and oidc.client_id are required")
+	}
+	if cfg.RedirectURL == "" {
+		domain := strings.TrimSuffix(sysconfig.GetString("system.domain", ""), "/")
+		if domain == "" {
+			return nil, fmt.Errorf("oidc.redirect_url or system.domain is required")
+		}
+		cfg.RedirectURL = domain + CallbackPath
+	}
+	if !containsString(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+
+	mapping, err := ParseRoleMapping(sysconfig.GetString("oidc.role_mapping", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.RoleMapping = mapping
+	return cfg, nil
+}
+
+// ParseRoleMapping 解析分组到角色的映射，格式为 "group=role,group2=role2"
+func ParseRoleMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		idx := strings.LastIndex(item, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid role mapping %q, expected group=role", item)
+		}
+		group, role := strings.TrimSpace(item[:idx]), strings.TrimSpace(item[idx+1:])
+		if !auth.ValidRole(role) {
+			return nil, fmt.Errorf("invalid role %q in role mapping", role)
+		}
+		mapping[group] = role
+	}
+	return mapping, nil
+}
+
+// discovery 发现文档中用到的字段
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+var (
+	discoveryMu    sync.Mutex
+	discoveryCache = make(map[string]cachedDiscovery)
+)
+
+// cachedDiscovery 缓存的发现文档
+type cachedDiscovery struct {
+	doc     *discovery
+	fetched time.Time
+}
+
+// discover 获取身份提供方的发现文档
+func discover(issuer string) (*discovery, error) {
+	discoveryMu.Lock()
+	defer discoveryMu.Unlock()
+
+	if cached, ok := discoveryCache[issuer]; ok && time.Since(cached.fetched) < discoveryTTL {
+		return cached.doc, nil
+	}
+
+	var doc discovery
+	if err := getJSON(issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %v", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", doc.Issuer, issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing endpoints")
+	}
+	discoveryCache[issuer] = cachedDiscovery{doc: &doc, fetched: time.Now()}
+	return &doc, nil
+}
+
+// pendingLogin 已发起但尚未回调的登录
+type pendingLogin struct {
+	verifier string
+	nonce    string
+	redirect string
+	expires  time.Time
+}
+
+var (
+	pendingMu sync.Mutex
+	pending   = make(map[string]pendingLogin)
+)
+
+// AuthURL 发起授权码 + PKCE 登录，返回跳转到身份提供方的地址和 state，
+// 调用方需将 state 写入 StateCookie。redirect 为登录成功后返回的站内路径
+func AuthURL(cfg *Config, redirect string) (string, string, error) {
+	doc, err := discover(cfg.Issuer)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	pendingMu.Lock()
+	for key, p := range pending {
+		if now.After(p.expires) {
+			delete(pending, key)
+		}
+	}
+	if len(pending) >= maxPending {
+		pendingMu.Unlock()
+		return "", "", ErrTooManyLogins
+	}
+	pending[state] = pendingLogin{verifier: verifier, nonce: nonce, redirect: redirect, expires: now.Add(StateTTL)}
+	pendingMu.Unlock()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", cfg.ClientID)
+	params.Set("redirect_uri", cfg.RedirectURL)
+	params.Set("scope", strings.Join(cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + params.Encode(), state, nil
+}
+
+// Identity 身份提供方返回的用户信息
+type Identity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Name     string
+	Groups   []string
+	Email    string
+	// EmailVerified 身份提供方是否验证过邮箱（email_verified 声明）
+	EmailVerified bool
+	Redirect      string // 发起登录时指定的站内路径
+}
+
+// Exchange 处理授权回调：校验 state 与浏览器 Cookie 中的一致且未过期，用授权码换取令牌并验证 ID Token
+func Exchange(cfg *Config, state, cookieState, code string) (*Identity, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		return nil, ErrInvalidState
+	}
+
+	pendingMu.Lock()
+	login, ok := pending[state]
+	delete(pending, state)
+	pendingMu.Unlock()
+	if !ok || time.Now().After(login.expires) {
+		return nil, ErrInvalidState
+	}
+
+	doc, err := discover(cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("code_verifier", login.verifier)
+	form.Set("client_id", cfg.ClientID)
+
+	req, err := http.NewRequest(http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("token request failed: %v", err)
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	claims, err := verifyIDToken(doc, cfg, token.IDToken, login.nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Issuer:   cfg.Issuer,
+		Subject:  claimString(claims, "sub"),
+		Username: claimString(claims, cfg.UsernameClaim),
+		Name:     claimString(claims, "name"),
+		Groups:   claimStrings(claims, cfg.GroupsClaim),
+		Email:    claimString(claims, "email"),
+		Redirect: login.redirect,
+	}
+	identity.EmailVerified = claimBool(claims, "email_verified")
+	if identity.Username == "" {
+		identity.Username = identity.Email
+	}
+	if identity.Username == "" {
+		identity.Username = identity.Subject
+	}
+	return identity, nil
+}
+
+// MapRole 根据分组计算角色，匹配多个分组时取权限最高的角色，没有匹配时使用默认角色
+func MapRole(cfg *Config, groups []string) string {
+	role := ""
+	for _, group := range groups {
+		if mapped, ok := cfg.RoleMapping[group]; ok && (role == "" || !auth.RoleAtLeast(role, mapped)) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = cfg.DefaultRole
+	}
+	return role
+}
+
+// getJSON 以 GET 请求获取 JSON
+func getJSON(url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return doJSON(req, v)
+}
+
+// doJSON 发送请求并解析 JSON 响应，令牌接口的错误响应也会被解析
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unexpected response (HTTP %d): %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// randomString 生成 URL 安全的随机字符串，用于 state、nonce 和 PKCE verifier
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// containsString 列表中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
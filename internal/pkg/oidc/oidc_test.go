@@ -0,0 +1,366 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockIdP 测试用的身份提供方，提供发现文档、JWKS 和令牌接口
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+
+	// 授权请求中的参数，令牌接口据此校验 PKCE 并签发 ID Token
+	nonce     string
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{t: t, rsaKey: rsaKey, ecKey: ecKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kid": "rsa", "kty": "RSA", "use": "sig",
+					"n": b64(rsaKey.N.Bytes()),
+					"e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+				},
+				{
+					"kid": "ec", "kty": "EC", "use": "sig", "crv": "P-256",
+					"x": b64(ecKey.X.FillBytes(make([]byte, 32))),
+					"y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id, secret, _ := r.BasicAuth()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if id != "client" || secret != "secret" || b64(sum[:]) != idp.challenge || r.PostForm.Get("code") != "code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := map[string]interface{}{"nonce": idp.nonce}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign("RS256", "rsa", claims)})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// config 指向模拟身份提供方的配置
+func (idp *mockIdP) config() *Config {
+	return &Config{
+		Issuer:        idp.server.URL,
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		RedirectURL:   "https://panel.example.com" + CallbackPath,
+		Scopes:        []string{"openid", "profile"},
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+	}
+}
+
+// baseClaims 有效的 ID Token 声明
+func (idp *mockIdP) baseClaims(nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   idp.server.URL,
+		"aud":   "client",
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+}
+
+// sign 按指定算法签发 JWT
+func (idp *mockIdP) sign(alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	var err error
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, idp.rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, idp.ecKey, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	}
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return signingInput + "." + b64(signature)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := newMockIdP(t)
+	cfg := idp.config()
+	doc, err := discover(cfg.Issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(changes map[string]interface{}) map[string]interface{} {
+		claims := idp.baseClaims("nonce-1")
+		for k, v := range changes {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"rs256", idp.sign("RS256", "rsa", with(nil)), false},
+		{"es256", idp.sign("ES256", "ec", with(nil)), false},
+		{"audience list", idp.sign("RS256", "rsa", with(map[string]interface{}{"aud": []string{"other", "client"}})), false},
+		{"within clock skew", idp.sign("RS256", "rsa", with(map[string]interface{}{"exp": time.Now().Add(-30 * time.Second).Unix()})), false},
+		{"malformed", "a.b", true},
+		{"alg none", b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{}`)) + ".", true},
+		{"unknown kid", idp.sign("RS256", "missing", with(nil)), true},
+		{"wrong key type", idp.sign("ES256", "rsa", with(nil)), true},
+		{"tampered payload", tamper(idp.sign("RS256", "rsa", with(nil)), with(map[string]interface{}{"sub": "admin"})), true},
+		{"issuer mismatch", idp.sign("RS256", "rsa", with(map[string]interface{}{"iss": "https://evil.example.com"})), true},
+		{"audience mismatch", idp.sign("RS256", "rsa", with(map[string]interface{}{"aud": "other"})), true},
+		{"expired", idp.sign("RS256", "rsa", with(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})), true},
+		{"missing exp", idp.sign("RS256", "rsa", with(map[string]interface{}{"exp": nil})), true},
+		{"not yet valid", idp.sign("RS256", "rsa", with(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})), true},
+		{"nonce mismatch", idp.sign("RS256", "rsa", with(map[string]interface{}{"nonce": "other"})), true},
+		{"missing subject", idp.sign("RS256", "rsa", with(map[string]interface{}{"sub": nil})), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifyIDToken(doc, cfg, tt.token, "nonce-1")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("verifyIDToken() = %v, want error", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyIDToken() error = %v", err)
+			}
+			if claimString(claims, "sub") != "user-1" {
+				t.Fatalf("sub = %q", claimString(claims, "sub"))
+			}
+		})
+	}
+}
+
+// tamper 替换 JWT 的声明部分，保留原签名
+func tamper(token string, claims map[string]interface{}) string {
+	payload, _ := json.Marshal(claims)
+	parts := strings.Split(token, ".")
+	parts[1] = b64(payload)
+	return strings.Join(parts, ".")
+}
+
+func TestLoginFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	cfg := idp.config()
+
+	// login 发起登录并模拟身份提供方记录授权请求的参数，返回 state
+	login := func(t *testing.T, claims map[string]interface{}) string {
+		target, state, err := AuthURL(cfg, "/scripts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := u.Query()
+		if q.Get("state") != state || q.Get("code_challenge_method") != "S256" || q.Get("redirect_uri") != cfg.RedirectURL {
+			t.Fatalf("unexpected authorization request %s", target)
+		}
+		idp.nonce = q.Get("nonce")
+		idp.challenge = q.Get("code_challenge")
+		idp.claims = claims
+		return state
+	}
+
+	claims := map[string]interface{}{
+		"iss":                idp.server.URL,
+		"aud":                "client",
+		"sub":                "user-1",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": "alice",
+		"name":               "Alice",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"groups":             []string{"ops", "dev"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		state := login(t, claims)
+		identity, err := Exchange(cfg, state, state, "code")
+		if err != nil {
+			t.Fatalf("Exchange() error = %v", err)
+		}
+		if identity.Subject != "user-1" || identity.Username != "alice" || identity.Name != "Alice" ||
+			!identity.EmailVerified || len(identity.Groups) != 2 || identity.Redirect != "/scripts" {
+			t.Fatalf("Exchange() = %+v", identity)
+		}
+
+		// state 只能使用一次
+		if _, err := Exchange(cfg, state, state, "code"); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("replayed state error = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("state cookie mismatch", func(t *testing.T) {
+		state := login(t, claims)
+		attacker := login(t, claims)
+		// 攻击者的回调地址在受害者的浏览器中打开，Cookie 中是受害者自己的 state
+		if _, err := Exchange(cfg, attacker, state, "code"); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("Exchange() error = %v, want ErrInvalidState", err)
+		}
+		if _, err := Exchange(cfg, attacker, "", "code"); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("Exchange() without cookie error = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		if _, err := Exchange(cfg, "forged", "forged", "code"); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("Exchange() error = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		state := login(t, claims)
+		if _, err := Exchange(cfg, state, state, "other"); err == nil {
+			t.Fatal("Exchange() with wrong code succeeded")
+		}
+	})
+
+	t.Run("username falls back to email", func(t *testing.T) {
+		withoutUsername := make(map[string]interface{})
+		for k, v := range claims {
+			withoutUsername[k] = v
+		}
+		delete(withoutUsername, "preferred_username")
+		withoutUsername["email_verified"] = "false"
+		state := login(t, withoutUsername)
+		identity, err := Exchange(cfg, state, state, "code")
+		if err != nil {
+			t.Fatalf("Exchange() error = %v", err)
+		}
+		if identity.Username != "alice@example.com" || identity.EmailVerified {
+			t.Fatalf("Exchange() = %+v", identity)
+		}
+	})
+}
+
+func TestPendingLimit(t *testing.T) {
+	idp := newMockIdP(t)
+	cfg := idp.config()
+
+	pendingMu.Lock()
+	saved := pending
+	pending = make(map[string]pendingLogin)
+	for i := 0; i < maxPending; i++ {
+		pending[fmt.Sprint("state-", i)] = pendingLogin{expires: time.Now().Add(time.Minute)}
+	}
+	pendingMu.Unlock()
+	defer func() {
+		pendingMu.Lock()
+		pending = saved
+		pendingMu.Unlock()
+	}()
+
+	if _, _, err := AuthURL(cfg, "/"); !errors.Is(err, ErrTooManyLogins) {
+		t.Fatalf("AuthURL() error = %v, want ErrTooManyLogins", err)
+	}
+
+	// 过期的登录会被清理，腾出位置
+	pendingMu.Lock()
+	for key, p := range pending {
+		p.expires = time.Now().Add(-time.Second)
+		pending[key] = p
+		break
+	}
+	pendingMu.Unlock()
+	if _, _, err := AuthURL(cfg, "/"); err != nil {
+		t.Fatalf("AuthURL() error = %v", err)
+	}
+}
+
+func TestMapRole(t *testing.T) {
+	cfg := &Config{
+		RoleMapping: map[string]string{"ops": "operator", "admins": "admin", "devs": "editor"},
+		DefaultRole: "viewer",
+	}
+	tests := []struct {
+		groups []string
+		want   string
+	}{
+		{nil, "viewer"},
+		{[]string{"unknown"}, "viewer"},
+		{[]string{"ops"}, "operator"},
+		{[]string{"ops", "devs"}, "editor"},
+		{[]string{"admins", "ops"}, "admin"},
+	}
+	for _, tt := range tests {
+		if got := MapRole(cfg, tt.groups); got != tt.want {
+			t.Errorf("MapRole(%v) = %q, want %q", tt.groups, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// roleNone 默认角色为 none 时拒绝没有匹配分组的用户登录
+const roleNone = "none"
+
+var (
+	// ErrUsernameTaken 用户名已被本地账号使用
+	ErrUsernameTaken = errors.New("username is already used by a local account")
+	// ErrNotProvisioned 未开启自动开通且账号不存在
+	ErrNotProvisioned = errors.New("account has not been provisioned")
+	// ErrNoRole 没有匹配的分组且默认角色为 none
+	ErrNoRole = errors.New("no role assigned")
+	// ErrUserDisabled 账号已被禁用
+	ErrUserDisabled = errors.New("user is disabled")
+)
+
+// ProvisionUser 查找或创建单点登录用户，并按分组同步角色，返回用户和同步前的状态（新建时为 nil），
+// 供调用方记录审计日志。已有的本地账号不会按用户名自动关联，只能由管理员在用户管理中指定 sub，
+// 或在开启 oidc.link_verified_email 时按身份提供方验证过的邮箱关联用户名相同的账号。
+// 开启自动开通时首次登录直接创建账号（不设密码，无法使用密码登录）
+func ProvisionUser(cfg *Config, identity *Identity) (*models.User, *models.User, error) {
+	role := MapRole(cfg, identity.Groups)
+	if role == "" || role == roleNone {
+		return nil, nil, ErrNoRole
+	}
+
+	db := database.GetDB()
+	var user models.User
+	err := db.Where("oidc_issuer = ? AND oidc_subject = ?", identity.Issuer, identity.Subject).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var existing models.User
+		err := db.Where("username = ?", identity.Username).First(&existing).Error
+		switch {
+		case err == nil && linkByEmail(cfg, identity, &existing):
+			before := existing
+			if err := db.Model(&existing).Updates(map[string]interface{}{
+				"oidc_issuer":  identity.Issuer,
+				"oidc_subject": identity.Subject,
+			}).Error; err != nil {
+				return nil, nil, fmt.Errorf("failed to link account: %v", err)
+			}
+			existing.OIDCIssuer, existing.OIDCSubject = identity.Issuer, identity.Subject
+			return syncUser(&existing, &before, role, identity)
+		case err == nil:
+			return nil, nil, ErrUsernameTaken
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil, err
+		case !cfg.AutoProvision:
+			return nil, nil, ErrNotProvisioned
+		default:
+			user = models.User{
+				Username:    identity.Username,
+				DisplayName: identity.Name,
+				Role:        role,
+				OIDCIssuer:  identity.Issuer,
+				OIDCSubject: identity.Subject,
+			}
+			if err := db.Create(&user).Error; err != nil {
+				return nil, nil, fmt.Errorf("failed to create user: %v", err)
+			}
+			return &user, nil, nil
+		}
+	}
+
+	before := user
+	return syncUser(&user, &before, role, identity)
+}
+
+// linkByEmail 是否按邮箱关联已有账号：需要管理员开启，邮箱经过身份提供方验证且与用户名相同，
+// 账号尚未关联其他单点登录身份
+func linkByEmail(cfg *Config, identity *Identity, existing *models.User) bool {
+	return cfg.LinkVerifiedEmail && identity.EmailVerified && identity.Email != "" &&
+		existing.Username == identity.Email && existing.OIDCSubject == ""
+}
+
+// syncUser 按身份提供方的信息同步角色和显示名称
+func syncUser(user, before *models.User, role string, identity *Identity) (*models.User, *models.User, error) {
+	if user.Disabled {
+		return nil, nil, ErrUserDisabled
+	}
+
+	updates := map[string]interface{}{}
+	if user.Role != role {
+		updates["role"] = role
+	}
+	if identity.Name != "" && user.DisplayName != identity.Name {
+		updates["display_name"] = identity.Name
+	}
+	if len(updates) > 0 {
+		if err := database.GetDB().Model(user).Updates(updates).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to update user: %v", err)
+		}
+		user.Role = role
+		if identity.Name != "" {
+			user.DisplayName = identity.Name
+		}
+	}
+	return user, before, nil
+}
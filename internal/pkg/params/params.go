@@ -0,0 +1,180 @@
+package params
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hook-panel/internal/models"
+)
+
+// EnvPrefix 参数环境变量前缀
+const EnvPrefix = "HOOK_PARAM_"
+
+// namePattern 参数名需要能直接作为环境变量名使用
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateDefinitions 校验脚本声明的参数定义
+func ValidateDefinitions(defs models.ScriptParameters) error {
+	seen := make(map[string]bool)
+	for _, def := range defs {
+		if !namePattern.MatchString(def.Name) {
+			return fmt.Errorf("invalid parameter name %q", def.Name)
+		}
+
+		key := strings.ToUpper(def.Name)
+		if seen[key] {
+			return fmt.Errorf("duplicate parameter %q", def.Name)
+		}
+		seen[key] = true
+
+		switch def.Type {
+		case models.ParameterTypeString, models.ParameterTypeNumber, models.ParameterTypeBool:
+		case models.ParameterTypeChoice:
+			if len(def.Options) == 0 {
+				return fmt.Errorf("parameter %q of type choice requires options", def.Name)
+			}
+		default:
+			return fmt.Errorf("parameter %q has unsupported type %q", def.Name, def.Type)
+		}
+
+		// 默认值也必须符合参数类型
+		if def.Default != "" {
+			if _, err := convert(def, def.Default); err != nil {
+				return fmt.Errorf("invalid default for parameter %q: %v", def.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve 按参数定义校验输入值，并补全默认值
+func Resolve(defs models.ScriptParameters, input map[string]interface{}) (map[string]string, error) {
+	defined := make(map[string]models.ScriptParameter, len(defs))
+	for _, def := range defs {
+		defined[def.Name] = def
+	}
+
+	for name := range input {
+		if _, ok := defined[name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+
+	values := make(map[string]string, len(defs))
+	for _, def := range defs {
+		raw, provided := input[def.Name]
+		if !provided || raw == nil || raw == "" {
+			if def.Default != "" {
+				values[def.Name] = def.Default
+				continue
+			}
+			if def.Required {
+				return nil, fmt.Errorf("parameter %q is required", def.Name)
+			}
+			values[def.Name] = ""
+			continue
+		}
+
+		value, err := convert(def, raw)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %v", def.Name, err)
+		}
+		values[def.Name] = value
+	}
+
+	return values, nil
+}
+
+// Env 将参数值转换为环境变量
+func Env(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, EnvPrefix+strings.ToUpper(name)+"="+values[name])
+	}
+	return env
+}
+
+// ValidateEnv 校验脚本附加的环境变量名
+func ValidateEnv(env map[string]string) error {
+	for name := range env {
+		if !namePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+	}
+	return nil
+}
+
+// ScriptEnv 将脚本附加的环境变量转换为 KEY=VALUE 列表
+func ScriptEnv(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]string, 0, len(names))
+	for _, name := range names {
+		list = append(list, name+"="+env[name])
+	}
+	return list
+}
+
+// convert 将输入值转换为参数类型对应的字符串形式
+func convert(def models.ScriptParameter, raw interface{}) (string, error) {
+	switch def.Type {
+	case models.ParameterTypeNumber:
+		switch v := raw.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return "", fmt.Errorf("%q is not a number", v)
+			}
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("expected a number")
+
+	case models.ParameterTypeBool:
+		switch v := raw.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return "", fmt.Errorf("%q is not a boolean", v)
+			}
+			return strconv.FormatBool(b), nil
+		}
+		return "", fmt.Errorf("expected a boolean")
+
+	case models.ParameterTypeChoice:
+		value := fmt.Sprintf("%v", raw)
+		for _, option := range def.Options {
+			if option == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not one of %s", value, strings.Join(def.Options, ", "))
+
+	default:
+		switch v := raw.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+		return "", fmt.Errorf("expected a string")
+	}
+}
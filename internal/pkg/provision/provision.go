@@ -0,0 +1,451 @@
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/executor"
+	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/gitstore"
+	"hook-panel/internal/pkg/params"
+	"hook-panel/internal/pkg/runner"
+	"hook-panel/internal/pkg/scheduler"
+	"hook-panel/internal/pkg/versions"
+	"hook-panel/internal/pkg/watcher"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// author 配置文件变更记录到版本历史和 Git 提交中的操作者
+const author = "config"
+
+var (
+	// configPath 配置文件路径，为空表示未启用
+	configPath string
+	// mutex 串行执行同步
+	mutex sync.Mutex
+)
+
+// Config 声明式配置文件
+type Config struct {
+	Scripts []ScriptSpec `json:"scripts"`
+}
+
+// ScriptSpec 配置文件中声明的脚本，按 ID 或名称与数据库中的脚本对应
+type ScriptSpec struct {
+	ID          string                  `json:"id"` // 新建时使用的脚本 ID，用于在多台服务器上保持相同的 Webhook 地址
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Group       string                  `json:"group"`
+	Executor    string                  `json:"executor"`
+	Enabled     *bool                   `json:"enabled"` // 默认启用
+	Content     string                  `json:"content"`
+	Path        string                  `json:"path"` // 脚本文件路径，与 content 二选一，相对路径基于配置文件所在目录
+	Env         models.StringMap        `json:"env"`
+	Parameters  models.ScriptParameters `json:"parameters"`
+	Retry       json.RawMessage         `json:"retry"` // 未设置的字段使用默认重试策略
+	Schedule    models.CronSchedule     `json:"schedule"`
+	Watch       models.FileWatch        `json:"watch"`
+	Chain       models.ScriptChain      `json:"chain"` // 引用配置文件中的脚本名称或已有脚本的 ID
+}
+
+// Init 启用声明式配置，立即同步一次，之后收到 SIGHUP 时重新同步
+func Init(path string) error {
+	if path == "" {
+		return nil
+	}
+	configPath = path
+	if err := Reconcile(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	if notifyReload(signals) {
+		go func() {
+			for range signals {
+				log.Printf("📄 Reloading configuration file %s", configPath)
+				if err := Reconcile(); err != nil {
+					log.Printf("Failed to apply configuration file, keeping current scripts: %v", err)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Reconcile 将数据库中的脚本同步为配置文件声明的状态。配置文件无效（包括链式触发形成循环）时不做任何修改；
+// 写入过程中出错时已同步的脚本不会回滚，修正问题后重新加载会继续同步剩余的脚本。
+// 已从配置文件中移除的受管脚本会被停用并解除管理，保留其运行记录和历史版本
+func Reconcile() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	specs, contents, err := load(configPath)
+	if err != nil {
+		return err
+	}
+
+	db := database.GetDB()
+	var scripts []models.Script
+	if err := db.Order("created_at ASC").Find(&scripts).Error; err != nil {
+		return fmt.Errorf("failed to query scripts: %v", err)
+	}
+
+	// 先为每个声明确定对应的已有脚本和 ID，再解析链式引用
+	matched := match(specs, scripts)
+	ids := make([]string, len(specs))
+	byName := make(map[string]string, len(specs))
+	existing := make(map[string]bool, len(scripts))
+	for _, script := range scripts {
+		existing[script.ID] = true
+	}
+	for i, spec := range specs {
+		switch {
+		case matched[i] != nil:
+			ids[i] = matched[i].ID
+		case spec.ID != "":
+			ids[i] = spec.ID
+		default:
+			ids[i] = uuid.New().String()
+		}
+		byName[spec.Name] = ids[i]
+	}
+
+	chains := make([]models.ScriptChain, len(specs))
+	for i, spec := range specs {
+		if chains[i], err = resolveChain(spec.Chain, byName, existing); err != nil {
+			return fmt.Errorf("script %q: invalid chain: %v", spec.Name, err)
+		}
+	}
+
+	// 在同步后的完整链式关系上检查循环（包括引用自身），与通过 API 保存时的规则一致
+	graph, err := runner.LoadChainGraph()
+	if err != nil {
+		return err
+	}
+	for i, spec := range specs {
+		graph.Set(ids[i], spec.Name, chains[i].Targets())
+	}
+	for i, spec := range specs {
+		if err := graph.Cycle(ids[i]); err != nil {
+			return fmt.Errorf("script %q: invalid chain: %v", spec.Name, err)
+		}
+	}
+
+	created, updated, released := 0, 0, 0
+	claimed := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		claimed[ids[i]] = true
+		desired := build(spec, ids[i], chains[i])
+		changed, err := apply(desired, matched[i], contents[i])
+		if err != nil {
+			return fmt.Errorf("script %q: %v", spec.Name, err)
+		}
+		if matched[i] == nil {
+			created++
+		} else if changed {
+			updated++
+		}
+		if matched[i] == nil || changed {
+			scheduler.Reload(desired.ID)
+			watcher.Reload(desired.ID)
+		}
+	}
+
+	for _, script := range scripts {
+		if !script.Managed || claimed[script.ID] {
+			continue
+		}
+		if err := db.Model(&script).Updates(map[string]interface{}{
+			"managed": false,
+			"enabled": false,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to release script %q: %v", script.Name, err)
+		}
+		scheduler.Reload(script.ID)
+		watcher.Reload(script.ID)
+		released++
+	}
+
+	log.Printf("📄 Configuration file %s applied: %d created, %d updated, %d released", configPath, created, updated, released)
+	return nil
+}
+
+// load 读取并校验配置文件，返回声明的脚本及其内容
+func load(path string) ([]ScriptSpec, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read configuration file: %v", err)
+	}
+
+	// 先解析为通用结构再按 JSON 字段名解码，与 API 使用相同的字段名
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration file: %v", err)
+	}
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration file: %v", err)
+	}
+	var config Config
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration file: %v", err)
+	}
+
+	names := make(map[string]bool, len(config.Scripts))
+	ids := make(map[string]bool, len(config.Scripts))
+	contents := make([]string, len(config.Scripts))
+	for i, spec := range config.Scripts {
+		if spec.Name == "" {
+			return nil, nil, fmt.Errorf("script #%d: name is required", i+1)
+		}
+		if names[spec.Name] {
+			return nil, nil, fmt.Errorf("duplicate script name %q", spec.Name)
+		}
+		names[spec.Name] = true
+		if spec.ID != "" {
+			if !file.ValidScriptID(spec.ID) {
+				return nil, nil, fmt.Errorf("script %q: invalid id %q", spec.Name, spec.ID)
+			}
+			if ids[spec.ID] {
+				return nil, nil, fmt.Errorf("duplicate script id %q", spec.ID)
+			}
+			ids[spec.ID] = true
+		}
+
+		if err := validate(spec); err != nil {
+			return nil, nil, fmt.Errorf("script %q: %v", spec.Name, err)
+		}
+
+		content := spec.Content
+		if spec.Path != "" {
+			scriptPath := spec.Path
+			if !filepath.IsAbs(scriptPath) {
+				scriptPath = filepath.Join(filepath.Dir(path), scriptPath)
+			}
+			data, err := os.ReadFile(scriptPath)
+			if err != nil {
+				return nil, nil, fmt.Errorf("script %q: failed to read %s: %v", spec.Name, spec.Path, err)
+			}
+			content = string(data)
+		}
+		contents[i] = content
+	}
+	return config.Scripts, contents, nil
+}
+
+// validate 校验单个脚本声明，与创建脚本时的校验一致
+func validate(spec ScriptSpec) error {
+	if !executor.Supported(spec.Executor) {
+		return fmt.Errorf("unsupported executor %q", spec.Executor)
+	}
+	if (spec.Content == "") == (spec.Path == "") {
+		return fmt.Errorf("exactly one of content and path is required")
+	}
+	if err := params.ValidateDefinitions(spec.Parameters); err != nil {
+		return fmt.Errorf("invalid parameters: %v", err)
+	}
+	if err := params.ValidateEnv(spec.Env); err != nil {
+		return fmt.Errorf("invalid environment variables: %v", err)
+	}
+	retry, err := retryPolicy(spec.Retry)
+	if err != nil {
+		return fmt.Errorf("invalid retry policy: %v", err)
+	}
+	if err := retry.Validate(); err != nil {
+		return fmt.Errorf("invalid retry policy: %v", err)
+	}
+	if err := scheduler.ValidateSchedule(spec.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %v", err)
+	}
+	if err := watcher.Validate(spec.Watch); err != nil {
+		return fmt.Errorf("invalid file watch: %v", err)
+	}
+	return nil
+}
+
+// retryPolicy 在默认重试策略上应用声明的字段
+func retryPolicy(raw json.RawMessage) (models.RetryPolicy, error) {
+	retry := models.DefaultRetryPolicy()
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &retry); err != nil {
+			return retry, err
+		}
+	}
+	return retry, nil
+}
+
+// match 查找每个声明对应的已有脚本：依次按 ID、受管脚本的名称、同名的未受管脚本匹配，
+// 按名称匹配到未受管脚本时由配置文件接管
+func match(specs []ScriptSpec, scripts []models.Script) []*models.Script {
+	matched := make([]*models.Script, len(specs))
+	claimed := make(map[string]bool, len(specs))
+	find := func(accept func(spec ScriptSpec, script models.Script) bool) {
+		for i, spec := range specs {
+			if matched[i] != nil {
+				continue
+			}
+			for j := range scripts {
+				if !claimed[scripts[j].ID] && accept(spec, scripts[j]) {
+					matched[i] = &scripts[j]
+					claimed[scripts[j].ID] = true
+					break
+				}
+			}
+		}
+	}
+
+	find(func(spec ScriptSpec, script models.Script) bool {
+		return spec.ID != "" && script.ID == spec.ID
+	})
+	find(func(spec ScriptSpec, script models.Script) bool {
+		return script.Managed && script.Name == spec.Name
+	})
+	find(func(spec ScriptSpec, script models.Script) bool {
+		return !script.Managed && script.Name == spec.Name
+	})
+	return matched
+}
+
+// resolveChain 将链式触发中的脚本名称解析为 ID
+func resolveChain(chain models.ScriptChain, byName map[string]string, existing map[string]bool) (models.ScriptChain, error) {
+	resolve := func(list models.StringList) (models.StringList, error) {
+		result := models.StringList{}
+		for _, ref := range list {
+			if id, ok := byName[ref]; ok {
+				result = append(result, id)
+			} else if existing[ref] {
+				result = append(result, ref)
+			} else {
+				return nil, fmt.Errorf("script %q not found", ref)
+			}
+		}
+		return result, nil
+	}
+
+	var resolved models.ScriptChain
+	var err error
+	if resolved.OnSuccess, err = resolve(chain.OnSuccess); err != nil {
+		return resolved, err
+	}
+	if resolved.OnFailure, err = resolve(chain.OnFailure); err != nil {
+		return resolved, err
+	}
+	resolved.Always, err = resolve(chain.Always)
+	return resolved, err
+}
+
+// build 根据声明生成脚本记录
+func build(spec ScriptSpec, id string, chain models.ScriptChain) models.Script {
+	enabled := true
+	if spec.Enabled != nil {
+		enabled = *spec.Enabled
+	}
+	retry, _ := retryPolicy(spec.Retry)
+	schedule := spec.Schedule
+	schedule.LastFiredAt = nil
+	if schedule.MissedRun == "" {
+		schedule.MissedRun = models.MissedRunSkip
+	}
+
+	return models.Script{
+		ID:          id,
+		Name:        spec.Name,
+		Description: spec.Description,
+		Group:       spec.Group,
+		Executor:    spec.Executor,
+		Enabled:     enabled,
+		Parameters:  spec.Parameters,
+		Env:         spec.Env,
+		Retry:       retry,
+		Schedule:    schedule,
+		Chain:       chain,
+		Watch:       spec.Watch,
+		Managed:     true,
+	}
+}
+
+// apply 写入单个脚本，内容或配置没有变化时不做修改
+func apply(desired models.Script, current *models.Script, content string) (bool, error) {
+	db := database.GetDB()
+	changed := false
+	if current == nil {
+		enabled := desired.Enabled
+		if err := db.Create(&desired).Error; err != nil {
+			return false, fmt.Errorf("failed to create script: %v", err)
+		}
+		// enabled 字段有默认值，创建时零值会被忽略
+		if !enabled {
+			if err := db.Model(&desired).Update("enabled", false).Error; err != nil {
+				return false, fmt.Errorf("failed to create script: %v", err)
+			}
+		}
+	} else if fingerprint(*current) != fingerprint(desired) {
+		desired.CallCount = current.CallCount
+		desired.LastCallAt = current.LastCallAt
+		desired.CreatedAt = current.CreatedAt
+		if desired.Schedule.Cron == current.Schedule.Cron && desired.Schedule.Timezone == current.Schedule.Timezone {
+			desired.Schedule.LastFiredAt = current.Schedule.LastFiredAt
+		}
+		if err := db.Save(&desired).Error; err != nil {
+			return false, fmt.Errorf("failed to update script: %v", err)
+		}
+		changed = true
+	}
+
+	existing, err := file.ReadScriptContent(desired.ID)
+	if err != nil {
+		return changed, err
+	}
+	if current != nil && existing == content && current.Executor == desired.Executor {
+		return changed, nil
+	}
+	if err := file.SaveScriptContent(desired.ID, content); err != nil {
+		return changed, err
+	}
+	if _, err := versions.Record(desired.ID, content, desired.Executor, author, "Apply configuration file"); err != nil {
+		return changed, err
+	}
+	if err := gitstore.Commit(desired.ID, author, "Apply configuration file to "+desired.Name); err != nil {
+		return changed, err
+	}
+	return true, nil
+}
+
+// fingerprint 生成脚本配置的比较值，忽略调用统计和时间字段
+func fingerprint(script models.Script) string {
+	script.CallCount = 0
+	script.LastCallAt = nil
+	script.CreatedAt = time.Time{}
+	script.UpdatedAt = time.Time{}
+	script.NextRunAt = nil
+	script.Schedule.LastFiredAt = nil
+	if script.Parameters == nil {
+		script.Parameters = models.ScriptParameters{}
+	}
+	if script.Env == nil {
+		script.Env = models.StringMap{}
+	}
+	if script.Retry.ExitCodes == nil {
+		script.Retry.ExitCodes = models.IntList{}
+	}
+	for _, list := range []*models.StringList{&script.Chain.OnSuccess, &script.Chain.OnFailure, &script.Chain.Always} {
+		if *list == nil {
+			*list = models.StringList{}
+		}
+	}
+
+	data, _ := json.Marshal(script)
+	return string(data)
+}
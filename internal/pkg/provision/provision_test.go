@@ -0,0 +1,113 @@
+package provision
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReconcileRejectsChainCycles(t *testing.T) {
+	db := setupTestDB(t)
+	existing := models.Script{ID: "existing", Name: "existing", Executor: "bash", Enabled: true, Retry: models.DefaultRetryPolicy()}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name: "two scripts",
+			config: `
+scripts:
+  - name: a
+    executor: bash
+    content: echo a
+    chain: {on_success: [b]}
+  - name: b
+    executor: bash
+    content: echo b
+    chain: {always: [a]}
+`,
+			want: `script "a": invalid chain: chain forms a cycle: a -> b -> a`,
+		},
+		{
+			name: "self reference",
+			config: `
+scripts:
+  - name: a
+    executor: bash
+    content: echo a
+    chain: {on_failure: [a]}
+`,
+			want: `script "a": invalid chain: chain forms a cycle: a -> a`,
+		},
+		{
+			name: "taking over an existing script",
+			config: `
+scripts:
+  - name: existing
+    executor: bash
+    content: echo existing
+    chain: {on_success: [c]}
+  - name: c
+    executor: bash
+    content: echo c
+    chain: {on_success: [existing]}
+`,
+			want: `script "existing": invalid chain: chain forms a cycle: existing -> c -> existing`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath = filepath.Join(t.TempDir(), "scripts.yaml")
+			if err := os.WriteFile(configPath, []byte(strings.TrimSpace(tt.config)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := Reconcile()
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("Reconcile() error = %v, want %q", err, tt.want)
+			}
+
+			// 配置文件无效时不做任何修改
+			var scripts []models.Script
+			if err := db.Find(&scripts).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(scripts) != 1 || scripts[0].Managed || len(scripts[0].Chain.Targets()) != 0 {
+				t.Errorf("scripts changed: %+v", scripts)
+			}
+		})
+	}
+}
+
+// setupTestDB 使用内存数据库替换全局数据库
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接各自独立，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Script{}, &models.SystemConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+	return db
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package provision
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReload 收到 SIGHUP 时重新同步配置文件
+func notifyReload(signals chan<- os.Signal) bool {
+	signal.Notify(signals, syscall.SIGHUP)
+	return true
+}
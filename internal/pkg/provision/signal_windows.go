@@ -0,0 +1,10 @@
+//go:build windows
+
+package provision
+
+import "os"
+
+// notifyReload Windows 没有 SIGHUP，只在启动时同步配置文件
+func notifyReload(signals chan<- os.Signal) bool {
+	return false
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"log"
+	"strconv"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/executor"
+)
+
+const (
+	// maxChainDepth 链式触发的最大深度。保存、导入脚本和应用配置文件时已拒绝循环，这里只作为兜底防止死循环
+	maxChainDepth = 10
+	// maxChainOutput 传递给后续脚本的输出长度上限，超出时保留末尾部分
+	maxChainOutput = 32 * 1024
+)
+
+// chain 根据运行结果异步触发后续脚本
+func (j *Job) chain(result *executor.ExecutionResult, runErr error) {
+	success := runErr == nil && result != nil && result.Success
+	next := j.Script.Chain.Next(success)
+	if len(next) == 0 || lifetime.Err() != nil {
+		return
+	}
+	if j.req.depth >= maxChainDepth {
+		log.Printf("Chain depth limit (%d) reached at script %s, follow-up scripts skipped", maxChainDepth, j.Script.ID)
+		return
+	}
+
+	// 传递上一个运行的结果，执行器未能启动时退出码记为 -1
+	runID, exitCode, output := "", -1, ""
+	if result != nil {
+		runID, exitCode, output = result.RunID, result.ExitCode, result.Output
+	} else if runErr != nil {
+		output = runErr.Error()
+	}
+	output = executor.Tail(output, maxChainOutput)
+	env := []string{
+		"HOOK_CHAIN_PARENT_SCRIPT_ID=" + j.Script.ID,
+		"HOOK_CHAIN_PARENT_RUN_ID=" + runID,
+		"HOOK_CHAIN_SUCCESS=" + strconv.FormatBool(success),
+		"HOOK_CHAIN_EXIT_CODE=" + strconv.Itoa(exitCode),
+		"HOOK_CHAIN_OUTPUT=" + output,
+		"HOOK_CHAIN_DEPTH=" + strconv.Itoa(j.req.depth+1),
+	}
+
+	// 后续脚本按列表顺序依次执行，标准输入沿用最初的请求内容
+	go func() {
+		for _, scriptID := range next {
+			_, err := Run(scriptID, Request{
+				Trigger: models.TriggerChain,
+				Stdin:   j.req.Stdin,
+				Env:     env,
+				Parent:  runID,
+				depth:   j.req.depth + 1,
+			})
+			if err != nil {
+				log.Printf("Chained run of script %s (after %s) failed: %v", scriptID, j.Script.ID, err)
+			}
+		}
+	}()
+}
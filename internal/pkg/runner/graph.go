@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+)
+
+// ChainGraph 脚本之间的链式触发关系，用于在保存、导入和声明式配置生效前拒绝循环。
+// 运行时的 maxChainDepth 只作为兜底
+type ChainGraph struct {
+	edges map[string][]string // 脚本 ID -> 后续脚本 ID
+	names map[string]string   // 脚本 ID -> 名称，用于错误信息
+}
+
+// LoadChainGraph 读取全部脚本的链式触发配置
+func LoadChainGraph() (*ChainGraph, error) {
+	var scripts []models.Script
+	if err := database.GetDB().Select("id", "name", "chain_on_success", "chain_on_failure", "chain_always").Find(&scripts).Error; err != nil {
+		return nil, fmt.Errorf("failed to query script chains: %v", err)
+	}
+	g := &ChainGraph{
+		edges: make(map[string][]string, len(scripts)),
+		names: make(map[string]string, len(scripts)),
+	}
+	for _, script := range scripts {
+		g.Set(script.ID, script.Name, script.Chain.Targets())
+	}
+	return g, nil
+}
+
+// Set 替换脚本的后续脚本，name 为空时保留原名称
+func (g *ChainGraph) Set(id, name string, targets []string) {
+	g.edges[id] = targets
+	if name != "" {
+		g.names[id] = name
+	}
+}
+
+// Remove 删除脚本的链式触发配置
+func (g *ChainGraph) Remove(id string) {
+	delete(g.edges, id)
+}
+
+// Edges 返回脚本当前的后续脚本
+func (g *ChainGraph) Edges(id string) []string {
+	return g.edges[id]
+}
+
+// Cycle 查找经过 id 的链式触发循环，返回形如 "a -> b -> a" 的错误，没有循环时返回 nil
+func (g *ChainGraph) Cycle(id string) error {
+	path := g.cycle(id)
+	if path == nil {
+		return nil
+	}
+	labels := make([]string, len(path))
+	for i, step := range path {
+		labels[i] = step
+		if name := g.names[step]; name != "" {
+			labels[i] = name
+		}
+	}
+	return fmt.Errorf("chain forms a cycle: %s", strings.Join(labels, " -> "))
+}
+
+// cycle 深度优先查找从 start 出发回到 start 的路径，不存在时返回 nil
+func (g *ChainGraph) cycle(start string) []string {
+	visited := make(map[string]bool)
+	var walk func(id string, path []string) []string
+	walk = func(id string, path []string) []string {
+		for _, next := range g.edges[id] {
+			if next == start {
+				return append(path, next)
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			if found := walk(next, append(path, next)); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return walk(start, []string{start})
+}
+
+// ValidateChain 校验脚本的链式触发配置：后续脚本必须存在，且不能直接或经过其他脚本回到脚本自身。
+// scriptID 为空表示新建的脚本，尚未被其他脚本引用
+func ValidateChain(chain models.ScriptChain, scriptID string) error {
+	db := database.GetDB()
+	for _, id := range chain.Targets() {
+		if id == scriptID {
+			return fmt.Errorf("script cannot chain to itself")
+		}
+		var count int64
+		if err := db.Model(&models.Script{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("script %s not found", id)
+		}
+	}
+	if scriptID == "" {
+		return nil
+	}
+
+	g, err := LoadChainGraph()
+	if err != nil {
+		return err
+	}
+	g.Set(scriptID, "", chain.Targets())
+	return g.Cycle(scriptID)
+}
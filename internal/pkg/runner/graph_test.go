@@ -0,0 +1,45 @@
+package runner
+
+import "testing"
+
+func TestChainGraphCycle(t *testing.T) {
+	g := &ChainGraph{
+		edges: map[string][]string{
+			"a": {"b"},
+			"b": {"c", "d"},
+			"c": {"a"},
+			"d": nil,
+			"e": {"e"},
+			"f": {"d", "b"},
+		},
+		names: map[string]string{"a": "deploy", "b": "build", "c": "notify"},
+	}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"a", "chain forms a cycle: deploy -> build -> notify -> deploy"},
+		{"b", "chain forms a cycle: build -> notify -> deploy -> build"},
+		{"d", ""},
+		{"e", "chain forms a cycle: e -> e"},
+		{"f", ""}, // 能到达循环但不在循环上
+		{"unknown", ""},
+	}
+	for _, tt := range tests {
+		err := g.Cycle(tt.id)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("Cycle(%s) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+
+	// 断开循环后不再报错
+	g.Set("c", "", nil)
+	if err := g.Cycle("a"); err != nil {
+		t.Errorf("Cycle(a) after removing c -> a = %v, want nil", err)
+	}
+}
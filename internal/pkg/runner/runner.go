@@ -0,0 +1,183 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/executor"
+	"hook-panel/internal/pkg/file"
+	"hook-panel/internal/pkg/params"
+	"hook-panel/internal/pkg/sysconfig"
+	"hook-panel/internal/pkg/versions"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrScriptNotFound 脚本不存在
+	ErrScriptNotFound = errors.New("script not found")
+	// ErrScriptDisabled 脚本已禁用
+	ErrScriptDisabled = errors.New("script is disabled")
+	// ErrEmptyContent 脚本内容为空
+	ErrEmptyContent = errors.New("script content is empty")
+	// ErrInvalidParams 参数校验失败
+	ErrInvalidParams = errors.New("invalid parameters")
+)
+
+var (
+	// lifetime 服务运行期间有效的上下文，关闭服务时取消，所有触发方式等待中的重试随之停止
+	lifetime, shutdown = context.WithCancel(context.Background())
+	// active 正在执行的运行数
+	active atomic.Int64
+)
+
+// Shutdown 关闭服务前调用：取消等待中的重试，不再触发链式后续脚本，
+// 并等待正在执行的运行写入结果，ctx 结束时不再等待
+func Shutdown(ctx context.Context) error {
+	shutdown()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for active.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("%d run(s) still in progress", active.Load())
+		}
+	}
+	return nil
+}
+
+// Request 运行请求
+type Request struct {
+	Trigger string                 // 触发来源
+	Params  map[string]interface{} // 参数值，按脚本声明的参数校验
+	Stdin   string                 // 写入脚本标准输入的内容
+	Env     []string               // 额外的环境变量
+	Parent  string                 // 链式触发时上一个运行的 ID
+	Dir     string                 // 工作目录
+	NoChain bool                   // 不触发脚本的链式配置（例如作为工作流步骤运行时）
+	NoRetry bool                   // 不按脚本的重试策略重试（手动执行时同步返回结果，不在请求中等待重试）
+
+	depth int // 链式触发深度
+}
+
+// Job 已完成校验、可直接执行的脚本运行任务
+type Job struct {
+	Script  models.Script
+	Version int // 执行的脚本版本
+	content string
+	env     []string
+	req     Request
+}
+
+// Prepare 加载脚本并校验是否可以运行
+func Prepare(scriptID string, req Request) (*Job, error) {
+	db := database.GetDB()
+	var script models.Script
+	if err := db.First(&script, "id = ?", scriptID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrScriptNotFound
+		}
+		return nil, err
+	}
+
+	if !script.Enabled {
+		return nil, ErrScriptDisabled
+	}
+
+	// 读取脚本内容
+	content, err := file.ReadScriptContent(scriptID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyContent
+	}
+
+	// 校验参数值并补全默认值
+	values, err := params.Resolve(script.Parameters, req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+
+	return &Job{
+		Script:  script,
+		Version: versions.Current(scriptID),
+		content: content,
+		env:     append(append(params.ScriptEnv(script.Env), params.Env(values)...), req.Env...),
+		req:     req,
+	}, nil
+}
+
+// Run 执行任务并更新调用统计，结束后按链式配置触发后续脚本
+func (j *Job) Run() (*executor.ExecutionResult, error) {
+	// 更新调用统计
+	database.GetDB().Model(&models.Script{}).
+		Where("id = ?", j.Script.ID).
+		Updates(map[string]interface{}{
+			"call_count":   gorm.Expr("call_count + 1"),
+			"last_call_at": time.Now(),
+		})
+
+	active.Add(1)
+	defer active.Add(-1)
+
+	retry := j.Script.Retry
+	if j.req.NoRetry {
+		retry.MaxAttempts = 1
+	}
+	result, err := newExecutor().ExecuteScript(j.Script.ID, j.content, j.Script.Executor, executor.ExecuteOptions{
+		Env:     j.env,
+		Stdin:   j.req.Stdin,
+		Trigger: j.req.Trigger,
+		Parent:  j.req.Parent,
+		Dir:     j.req.Dir,
+		Version: j.Version,
+		Retry:   retry,
+		Context: lifetime,
+	})
+
+	// 触发后续脚本
+	if !j.req.NoChain {
+		j.chain(result, err)
+	}
+	return result, err
+}
+
+// Run 加载并同步执行脚本
+func Run(scriptID string, req Request) (*executor.ExecutionResult, error) {
+	job, err := Prepare(scriptID, req)
+	if err != nil {
+		return nil, err
+	}
+	return job.Run()
+}
+
+// RunInline 执行未保存为脚本的内联内容，ownerID 用于记录日志和运行记录
+func RunInline(ownerID, content, executorName string, req Request) (*executor.ExecutionResult, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyContent
+	}
+	active.Add(1)
+	defer active.Add(-1)
+	return newExecutor().ExecuteScript(ownerID, content, executorName, executor.ExecuteOptions{
+		Env:     req.Env,
+		Stdin:   req.Stdin,
+		Trigger: req.Trigger,
+		Parent:  req.Parent,
+		Dir:     req.Dir,
+		Context: lifetime,
+	})
+}
+
+// newExecutor 按系统配置的超时时间创建执行器
+func newExecutor() *executor.ScriptExecutor {
+	timeout := time.Duration(sysconfig.GetInt("webhook.timeout", 60)) * time.Second
+	return executor.NewScriptExecutor(timeout)
+}
@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForActiveRuns(t *testing.T) {
+	active.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Shutdown(ctx); err == nil {
+		t.Fatal("Shutdown should report runs still in progress")
+	}
+	if lifetime.Err() == nil {
+		t.Fatal("Shutdown should cancel pending retries")
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { active.Add(-1) })
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v, want nil once runs finish", err)
+	}
+}
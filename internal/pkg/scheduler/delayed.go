@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/runner"
+)
+
+// ErrNotPending 计划运行已执行或已取消，无法再取消
+var ErrNotPending = errors.New("scheduled run is not pending")
+
+var (
+	// timers 等待中的一次性运行计时器，scheduledRunID -> timer
+	timers      = make(map[string]*time.Timer)
+	timersMutex sync.Mutex
+)
+
+// Enqueue 为计划运行设置计时器，已过期的会立即执行
+func Enqueue(run models.ScheduledRun) {
+	id := run.ID
+
+	// 持锁创建计时器，保证立即触发时回调能看到已登记的计时器
+	timersMutex.Lock()
+	defer timersMutex.Unlock()
+	timers[id] = time.AfterFunc(time.Until(run.RunAt), func() {
+		fireDelayed(id)
+	})
+}
+
+// Cancel 取消等待中的计划运行
+func Cancel(id string) error {
+	result := database.GetDB().Model(&models.ScheduledRun{}).
+		Where("id = ? AND status = ?", id, models.ScheduledRunPending).
+		Updates(map[string]interface{}{
+			"status":      models.ScheduledRunCancelled,
+			"finished_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotPending
+	}
+
+	stopTimer(id)
+	return nil
+}
+
+// CancelScript 取消脚本的全部等待中的计划运行，删除脚本时调用
+func CancelScript(scriptID string) {
+	var ids []string
+	database.GetDB().Model(&models.ScheduledRun{}).
+		Where("script_id = ? AND status = ?", scriptID, models.ScheduledRunPending).
+		Pluck("id", &ids)
+	for _, id := range ids {
+		Cancel(id)
+	}
+}
+
+// loadDelayed 启动时恢复等待中的计划运行
+func loadDelayed() (int, error) {
+	db := database.GetDB()
+
+	// 上次退出时仍在执行的计划运行已被中断
+	db.Model(&models.ScheduledRun{}).
+		Where("status = ?", models.ScheduledRunRunning).
+		Updates(map[string]interface{}{
+			"status":      models.ScheduledRunFailed,
+			"error":       "interrupted by service restart",
+			"finished_at": time.Now(),
+		})
+
+	var runs []models.ScheduledRun
+	if err := db.Where("status = ?", models.ScheduledRunPending).Find(&runs).Error; err != nil {
+		return 0, fmt.Errorf("failed to load scheduled runs: %v", err)
+	}
+	for _, run := range runs {
+		Enqueue(run)
+	}
+	return len(runs), nil
+}
+
+// fireDelayed 执行计划运行
+func fireDelayed(id string) {
+	stopTimer(id)
+
+	db := database.GetDB()
+
+	// 通过状态条件抢占，避免与取消操作并发
+	claim := db.Model(&models.ScheduledRun{}).
+		Where("id = ? AND status = ?", id, models.ScheduledRunPending).
+		Update("status", models.ScheduledRunRunning)
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+
+	var run models.ScheduledRun
+	if err := db.First(&run, "id = ?", id).Error; err != nil {
+		log.Printf("Failed to load scheduled run %s: %v", id, err)
+		return
+	}
+
+	updates := map[string]interface{}{
+		"status": models.ScheduledRunDone,
+	}
+	result, err := runner.Run(run.ScriptID, runner.Request{
+		Trigger: models.TriggerDelayed,
+		Params:  run.Params,
+		Stdin:   run.Stdin,
+	})
+	if err != nil {
+		log.Printf("Scheduled run %s of script %s failed: %v", id, run.ScriptID, err)
+		updates["status"] = models.ScheduledRunFailed
+		updates["error"] = err.Error()
+	} else {
+		updates["run_id"] = result.RunID
+		if !result.Success {
+			updates["status"] = models.ScheduledRunFailed
+			updates["error"] = result.Error
+		}
+	}
+	updates["finished_at"] = time.Now()
+
+	db.Model(&models.ScheduledRun{}).Where("id = ?", id).Updates(updates)
+}
+
+// stopTimer 停止并移除计时器
+func stopTimer(id string) {
+	timersMutex.Lock()
+	defer timersMutex.Unlock()
+	if timer, ok := timers[id]; ok {
+		timer.Stop()
+		delete(timers, id)
+	}
+}
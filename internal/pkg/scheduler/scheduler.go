@@ -0,0 +1,220 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/runner"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parser cron 表达式解析器，秒字段可选，支持 @daily 等描述符
+var parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	cron    *cron.Cron
+	entries map[string]cron.EntryID // scriptID -> entryID
+	mutex   sync.RWMutex
+}
+
+// 全局调度器实例
+var scheduler *Scheduler
+
+// Start 加载所有定时脚本和等待中的计划运行并启动调度器
+func Start() error {
+	scheduler = &Scheduler{
+		cron: cron.New(
+			cron.WithParser(parser),
+			cron.WithChain(cron.Recover(cron.DefaultLogger)),
+		),
+		entries: make(map[string]cron.EntryID),
+	}
+
+	var scripts []models.Script
+	if err := database.GetDB().Where("enabled = ? AND schedule_cron <> ''", true).Find(&scripts).Error; err != nil {
+		return fmt.Errorf("failed to load scheduled scripts: %v", err)
+	}
+
+	now := time.Now()
+	for _, script := range scripts {
+		if err := scheduler.add(script); err != nil {
+			log.Printf("Failed to schedule script %s: %v", script.ID, err)
+			continue
+		}
+		handleMissedRun(script, now)
+	}
+
+	pending, err := loadDelayed()
+	if err != nil {
+		return err
+	}
+
+	scheduler.cron.Start()
+	log.Printf("⏰ Scheduler started with %d scheduled scripts and %d pending runs", len(scripts), pending)
+	return nil
+}
+
+// Validate 校验 cron 表达式和时区
+func Validate(expr, timezone string) error {
+	if expr == "" {
+		return nil
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", timezone)
+		}
+	}
+	if _, err := parse(expr, timezone); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %v", expr, err)
+	}
+	return nil
+}
+
+// ValidateSchedule 校验脚本的定时配置
+func ValidateSchedule(schedule models.CronSchedule) error {
+	if schedule.MissedRun != "" && schedule.MissedRun != models.MissedRunSkip && schedule.MissedRun != models.MissedRunRunOnce {
+		return fmt.Errorf("missed_run must be %s or %s", models.MissedRunSkip, models.MissedRunRunOnce)
+	}
+	return Validate(schedule.Cron, schedule.Timezone)
+}
+
+// Reload 重新加载脚本的定时配置，脚本修改、启停后调用
+func Reload(scriptID string) {
+	if scheduler == nil {
+		return
+	}
+	scheduler.remove(scriptID)
+
+	var script models.Script
+	if err := database.GetDB().First(&script, "id = ?", scriptID).Error; err != nil {
+		return
+	}
+	if !script.Enabled || script.Schedule.Cron == "" {
+		return
+	}
+	if err := scheduler.add(script); err != nil {
+		log.Printf("Failed to schedule script %s: %v", scriptID, err)
+	}
+}
+
+// Remove 移除脚本的定时任务
+func Remove(scriptID string) {
+	if scheduler != nil {
+		scheduler.remove(scriptID)
+	}
+}
+
+// NextRun 获取脚本下次定时触发的时间，未定时返回 nil
+func NextRun(scriptID string) *time.Time {
+	if scheduler == nil {
+		return nil
+	}
+
+	scheduler.mutex.RLock()
+	id, ok := scheduler.entries[scriptID]
+	scheduler.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	next := scheduler.cron.Entry(id).Next
+	if next.IsZero() {
+		// 调度器尚未启动时 Next 为空，直接计算
+		var script models.Script
+		if err := database.GetDB().First(&script, "id = ?", scriptID).Error; err != nil {
+			return nil
+		}
+		schedule, err := parse(script.Schedule.Cron, script.Schedule.Timezone)
+		if err != nil {
+			return nil
+		}
+		next = schedule.Next(time.Now())
+	}
+	return &next
+}
+
+// add 为脚本添加定时任务
+func (s *Scheduler) add(script models.Script) error {
+	schedule, err := parse(script.Schedule.Cron, script.Schedule.Timezone)
+	if err != nil {
+		return err
+	}
+
+	// 首次调度时记录基准时间，用于之后判断停机期间是否错过触发
+	if script.Schedule.LastFiredAt == nil {
+		markFired(script.ID, time.Now())
+	}
+
+	scriptID := script.ID
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(func() {
+		fire(scriptID)
+	}))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[scriptID] = s.cron.Schedule(schedule, job)
+	return nil
+}
+
+// remove 移除脚本的定时任务
+func (s *Scheduler) remove(scriptID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if id, ok := s.entries[scriptID]; ok {
+		s.cron.Remove(id)
+		delete(s.entries, scriptID)
+	}
+}
+
+// fire 定时触发脚本
+func fire(scriptID string) {
+	markFired(scriptID, time.Now())
+
+	result, err := runner.Run(scriptID, runner.Request{Trigger: models.TriggerSchedule})
+	if err != nil {
+		log.Printf("Scheduled run of script %s failed: %v", scriptID, err)
+		return
+	}
+	if !result.Success {
+		log.Printf("Scheduled run of script %s exited with code %d", scriptID, result.ExitCode)
+	}
+}
+
+// handleMissedRun 按策略处理停机期间错过的触发
+func handleMissedRun(script models.Script, now time.Time) {
+	if script.Schedule.MissedRun != models.MissedRunRunOnce || script.Schedule.LastFiredAt == nil {
+		return
+	}
+
+	schedule, err := parse(script.Schedule.Cron, script.Schedule.Timezone)
+	if err != nil {
+		return
+	}
+
+	// 上次触发之后的下一个触发点早于当前时间，说明停机期间至少错过了一次
+	if missed := schedule.Next(*script.Schedule.LastFiredAt); missed.Before(now) {
+		log.Printf("⏰ Script %s missed its schedule at %s, running once now", script.ID, missed.Format("2006-01-02 15:04:05"))
+		go fire(script.ID)
+	}
+}
+
+// markFired 记录最近一次触发时间
+func markFired(scriptID string, at time.Time) {
+	database.GetDB().Model(&models.Script{}).
+		Where("id = ?", scriptID).
+		UpdateColumn("schedule_last_fired_at", at)
+}
+
+// parse 解析 cron 表达式，时区通过 CRON_TZ 前缀传入
+func parse(expr, timezone string) (cron.Schedule, error) {
+	if timezone != "" {
+		expr = "CRON_TZ=" + timezone + " " + expr
+	}
+	return parser.Parse(expr)
+}
@@ -0,0 +1,161 @@
+package sysconfig
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+)
+
+const (
+	// MaskedValue 加密配置返回给前端时使用的掩码，提交掩码表示不修改
+	MaskedValue = "******"
+
+	// ConfigKeyFile 加密配置使用的数据密钥，与访问密钥分开保存，轮换访问密钥不影响已加密的配置
+	ConfigKeyFile = "./data/config.key"
+	// ConfigKeyEnv 直接提供数据密钥（64 个十六进制字符）的环境变量
+	ConfigKeyEnv = "HOOK_PANEL_CONFIG_KEY"
+
+	// encryptedPrefix 加密值的前缀，没有前缀的值视为尚未加密的旧数据
+	encryptedPrefix = "enc:v1:"
+	configKeySize   = 32 // AES-256
+)
+
+// ErrNoConfigKey 存在加密的配置但找不到数据密钥
+var ErrNoConfigKey = errors.New("config encryption key not found")
+
+var (
+	aeadMu sync.Mutex
+	aead   cipher.AEAD
+)
+
+// Encrypt 使用 AES-GCM 加密配置值，配置名作为附加数据，防止密文在配置项之间互换。空值不加密
+func Encrypt(key, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	gcm, err := loadCipher(true)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), []byte(key))
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 生成的值，key 必须与加密时一致。没有加密前缀的值原样返回
+func Decrypt(key, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	gcm, err := loadCipher(false)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value for %s", key)
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %v", key, err)
+	}
+	return string(plain), nil
+}
+
+// IsEncrypted 判断值是否已经加密
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EncryptExisting 加密标记为 Encrypted 但仍以明文保存的配置，启动时调用
+func EncryptExisting() error {
+	db := database.GetDB()
+	var configs []models.SystemConfig
+	if err := db.Where("encrypted = ? AND value <> ''", true).Find(&configs).Error; err != nil {
+		return fmt.Errorf("failed to query encrypted configs: %v", err)
+	}
+
+	count := 0
+	for _, config := range configs {
+		if IsEncrypted(config.Value) {
+			continue
+		}
+		value, err := Encrypt(config.Key, config.Value)
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&config).Update("value", value).Error; err != nil {
+			return fmt.Errorf("failed to encrypt config %s: %v", config.Key, err)
+		}
+		count++
+	}
+	if count > 0 {
+		log.Printf("🔒 Encrypted %d plaintext config value(s)", count)
+	}
+	return nil
+}
+
+// loadCipher 加载数据密钥，优先使用环境变量，其次为 ConfigKeyFile。create 为 true 时密钥不存在则生成
+func loadCipher(create bool) (cipher.AEAD, error) {
+	aeadMu.Lock()
+	defer aeadMu.Unlock()
+	if aead != nil {
+		return aead, nil
+	}
+
+	encoded := strings.TrimSpace(os.Getenv(ConfigKeyEnv))
+	source := ConfigKeyEnv
+	if encoded == "" {
+		source = ConfigKeyFile
+		content, err := os.ReadFile(ConfigKeyFile)
+		switch {
+		case err == nil:
+			encoded = strings.TrimSpace(string(content))
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("failed to read config key file: %v", err)
+		case !create:
+			return nil, ErrNoConfigKey
+		default:
+			b := make([]byte, configKeySize)
+			if _, err := rand.Read(b); err != nil {
+				return nil, fmt.Errorf("failed to generate config key: %v", err)
+			}
+			encoded = hex.EncodeToString(b)
+			if err := os.WriteFile(ConfigKeyFile, []byte(encoded), 0600); err != nil {
+				return nil, fmt.Errorf("failed to save config key file: %v", err)
+			}
+			log.Printf("🔑 Generated config encryption key %s, keep a backup of it separate from the database", ConfigKeyFile)
+		}
+	}
+
+	key, err := hex.DecodeString(encoded)
+	if err != nil || len(key) != configKeySize {
+		return nil, fmt.Errorf("config key from %s must be %d hex characters", source, configKeySize*2)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	aead = gcm
+	return aead, nil
+}
@@ -0,0 +1,146 @@
+package sysconfig
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useConfigKey 使用指定的数据密钥，清除已缓存的密钥，测试结束后恢复
+func useConfigKey(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(ConfigKeyEnv, key)
+	resetCipher()
+	t.Cleanup(resetCipher)
+}
+
+func resetCipher() {
+	aeadMu.Lock()
+	defer aeadMu.Unlock()
+	aead = nil
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	useConfigKey(t, strings.Repeat("01", 32))
+
+	for _, value := range []string{"secret", "with spaces and 中文", strings.Repeat("x", 4096)} {
+		sealed, err := Encrypt("oidc.client_secret", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(sealed) || strings.Contains(sealed, value) {
+			t.Fatalf("Encrypt(%q) = %q, want an encrypted value", value, sealed)
+		}
+		plain, err := Decrypt("oidc.client_secret", sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plain != value {
+			t.Errorf("Decrypt() = %q, want %q", plain, value)
+		}
+	}
+
+	// 每次加密使用新的 nonce
+	a, _ := Encrypt("k", "same")
+	b, _ := Encrypt("k", "same")
+	if a == b {
+		t.Error("encrypting the same value twice must not produce the same ciphertext")
+	}
+
+	// 空值不加密，旧的明文原样返回
+	if sealed, _ := Encrypt("k", ""); sealed != "" {
+		t.Errorf("Encrypt(\"\") = %q, want empty", sealed)
+	}
+	if plain, err := Decrypt("k", "legacy plaintext"); err != nil || plain != "legacy plaintext" {
+		t.Errorf("Decrypt(plaintext) = %q, %v", plain, err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	useConfigKey(t, strings.Repeat("02", 32))
+
+	sealed, err := Encrypt("storage.git_remote", "https://token@example.com/repo.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, encryptedPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(i int) string {
+		b := append([]byte(nil), raw...)
+		b[i] ^= 0x01
+		return encryptedPrefix + base64.StdEncoding.EncodeToString(b)
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"flipped nonce", "storage.git_remote", flip(0)},
+		{"flipped ciphertext", "storage.git_remote", flip(len(raw) / 2)},
+		{"flipped tag", "storage.git_remote", flip(len(raw) - 1)},
+		{"truncated", "storage.git_remote", encryptedPrefix + base64.StdEncoding.EncodeToString(raw[:len(raw)-1])},
+		{"shorter than nonce", "storage.git_remote", encryptedPrefix + base64.StdEncoding.EncodeToString(raw[:4])},
+		{"invalid base64", "storage.git_remote", encryptedPrefix + "!!!"},
+		{"moved to another key", "oidc.client_secret", sealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plain, err := Decrypt(tt.key, tt.value); err == nil {
+				t.Errorf("Decrypt() = %q, want an error", plain)
+			}
+		})
+	}
+
+	// 更换数据密钥后无法解密
+	useConfigKey(t, strings.Repeat("03", 32))
+	if _, err := Decrypt("storage.git_remote", sealed); err == nil {
+		t.Error("Decrypt with a different data key should fail")
+	}
+}
+
+func TestConfigKeyFile(t *testing.T) {
+	useConfigKey(t, "")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.MkdirAll("data", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有密钥时解密不会生成新密钥
+	if _, err := Decrypt("k", encryptedPrefix+"AAAA"); !errors.Is(err, ErrNoConfigKey) {
+		t.Fatalf("Decrypt without key: err = %v, want ErrNoConfigKey", err)
+	}
+	if _, err := os.Stat(ConfigKeyFile); !os.IsNotExist(err) {
+		t.Fatal("Decrypt must not create a key file")
+	}
+
+	// 首次加密时生成密钥文件，重新加载后仍能解密
+	sealed, err := Encrypt("k", "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ConfigKeyFile); err != nil {
+		t.Fatalf("key file not created: %v", err)
+	}
+	resetCipher()
+	if plain, err := Decrypt("k", sealed); err != nil || plain != "value" {
+		t.Errorf("Decrypt after reload = %q, %v", plain, err)
+	}
+
+	// 格式错误的密钥
+	useConfigKey(t, "not-hex")
+	if _, err := Encrypt("k", "value"); err == nil {
+		t.Error("Encrypt with an invalid key should fail")
+	}
+}
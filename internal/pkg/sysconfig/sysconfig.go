@@ -0,0 +1,66 @@
+package sysconfig
+
+import (
+	"strconv"
+	"strings"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// Get 获取单个配置值，配置不存在时返回空字符串。加密保存的配置在这里解密
+func Get(key string) (string, error) {
+	db := database.GetDB()
+	if db == nil {
+		return "", nil
+	}
+
+	var config models.SystemConfig
+	if err := db.Where("key = ?", key).First(&config).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil // 配置不存在返回空字符串
+		}
+		return "", err
+	}
+
+	return Decrypt(config.Key, config.Value)
+}
+
+// GetString 获取字符串配置，读取失败或为空时返回默认值
+func GetString(key, fallback string) string {
+	value, err := Get(key)
+	if err != nil || strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return strings.TrimSpace(value)
+}
+
+// GetInt 获取整数配置，读取失败或格式错误时返回默认值
+func GetInt(key string, fallback int) int {
+	value, err := Get(key)
+	if err != nil {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// GetBool 获取布尔配置，读取失败或格式错误时返回默认值
+func GetBool(key string, fallback bool) bool {
+	value, err := Get(key)
+	if err != nil {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
@@ -0,0 +1,112 @@
+package templates
+
+import "hook-panel/internal/models"
+
+// builtins 内置模板
+var builtins = []models.ScriptTemplate{
+	{
+		ID:          "builtin-git-pull-systemd",
+		Name:        "Git pull + restart systemd service",
+		Description: "Update a git checkout and restart the systemd service that runs it",
+		Executor:    "bash",
+		BuiltIn:     true,
+		Parameters: models.ScriptParameters{
+			{Name: "repo_dir", Label: "Repository directory", Type: models.ParameterTypeString, Required: true},
+			{Name: "branch", Label: "Branch", Type: models.ParameterTypeString, Default: "main"},
+			{Name: "service", Label: "systemd service", Type: models.ParameterTypeString, Required: true},
+			{Name: "use_sudo", Label: "Restart with sudo", Type: models.ParameterTypeBool, Default: "false"},
+		},
+		Content: `#!/bin/bash
+set -euo pipefail
+
+cd {{quote .repo_dir}}
+echo "Updating $(pwd) from origin/"{{quote .branch}}
+git fetch --prune origin
+git checkout {{quote .branch}}
+git pull --ff-only origin {{quote .branch}}
+git log -1 --oneline
+
+echo "Restarting "{{quote .service}}
+{{if eq .use_sudo "true"}}sudo {{end}}systemctl restart {{quote .service}}
+{{if eq .use_sudo "true"}}sudo {{end}}systemctl is-active --quiet {{quote .service}}
+echo "Service is active"
+`,
+	},
+	{
+		ID:          "builtin-docker-compose-redeploy",
+		Name:        "Docker Compose redeploy",
+		Description: "Pull the latest images and recreate the containers of a Compose project",
+		Executor:    "bash",
+		BuiltIn:     true,
+		Parameters: models.ScriptParameters{
+			{Name: "project_dir", Label: "Project directory", Type: models.ParameterTypeString, Required: true},
+			{Name: "compose_file", Label: "Compose file", Type: models.ParameterTypeString, Default: "docker-compose.yml"},
+			{Name: "pull", Label: "Pull images first", Type: models.ParameterTypeBool, Default: "true"},
+			{Name: "prune", Label: "Prune dangling images", Type: models.ParameterTypeBool, Default: "false"},
+		},
+		Content: `#!/bin/bash
+set -euo pipefail
+
+cd {{quote .project_dir}}
+{{- if eq .pull "true"}}
+docker compose -f {{quote .compose_file}} pull
+{{- end}}
+docker compose -f {{quote .compose_file}} up -d --remove-orphans
+docker compose -f {{quote .compose_file}} ps
+{{- if eq .prune "true"}}
+docker image prune -f
+{{- end}}
+`,
+	},
+	{
+		ID:          "builtin-rsync-static-site",
+		Name:        "Static site rsync",
+		Description: "Optionally build a static site, then sync it to the web root",
+		Executor:    "bash",
+		BuiltIn:     true,
+		Parameters: models.ScriptParameters{
+			{Name: "source_dir", Label: "Source directory", Description: "Directory that is synced, e.g. the build output", Type: models.ParameterTypeString, Required: true},
+			{Name: "destination", Label: "Destination", Description: "Local path or user@host:/path", Type: models.ParameterTypeString, Required: true},
+			{Name: "build_command", Label: "Build command", Description: "Runs in the source directory's parent before syncing, leave empty to skip", Type: models.ParameterTypeString},
+			{Name: "delete", Label: "Delete removed files", Type: models.ParameterTypeBool, Default: "true"},
+		},
+		Content: `#!/bin/bash
+set -euo pipefail
+{{if .build_command}}
+(cd "$(dirname {{quote .source_dir}})" && {{.build_command}})
+{{end}}
+rsync -az --stats {{if eq .delete "true"}}--delete {{end}}{{quote .source_dir}}/ {{quote .destination}}
+`,
+	},
+	{
+		ID:          "builtin-database-backup",
+		Name:        "Database backup",
+		Description: "Dump a MySQL or PostgreSQL database to a compressed file and remove old backups. Pass the password with the script env (MYSQL_PWD or PGPASSWORD)",
+		Executor:    "bash",
+		BuiltIn:     true,
+		Parameters: models.ScriptParameters{
+			{Name: "engine", Label: "Database engine", Type: models.ParameterTypeChoice, Default: "postgres", Options: []string{"postgres", "mysql"}},
+			{Name: "database", Label: "Database name", Type: models.ParameterTypeString, Required: true},
+			{Name: "host", Label: "Host", Type: models.ParameterTypeString, Default: "localhost"},
+			{Name: "user", Label: "User", Type: models.ParameterTypeString},
+			{Name: "backup_dir", Label: "Backup directory", Type: models.ParameterTypeString, Default: "/var/backups/db"},
+			{Name: "keep_days", Label: "Days to keep", Type: models.ParameterTypeNumber, Default: "7"},
+		},
+		Content: `#!/bin/bash
+set -euo pipefail
+
+backup_dir={{quote .backup_dir}}
+mkdir -p "$backup_dir"
+file="$backup_dir/"{{quote .database}}"-$(date +%Y%m%d-%H%M%S).sql.gz"
+
+{{if eq .engine "mysql" -}}
+mysqldump -h {{quote .host}} {{if .user}}-u {{quote .user}} {{end}}--single-transaction {{quote .database}} | gzip > "$file"
+{{- else -}}
+pg_dump -h {{quote .host}} {{if .user}}-U {{quote .user}} {{end}}{{quote .database}} | gzip > "$file"
+{{- end}}
+echo "Backup written to $file ($(du -h "$file" | cut -f1))"
+
+find "$backup_dir" -name {{quote (printf "%s-*.sql.gz" .database)}} -mtime +{{.keep_days}} -print -delete
+`,
+	},
+}
@@ -0,0 +1,107 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/params"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 模板不存在
+var ErrNotFound = errors.New("template not found")
+
+// funcs 模板中可用的函数
+var funcs = template.FuncMap{
+	"quote": quote,
+}
+
+// List 获取全部模板，内置模板在前
+func List() ([]models.ScriptTemplate, error) {
+	var saved []models.ScriptTemplate
+	if err := database.GetDB().Order("created_at DESC").Find(&saved).Error; err != nil {
+		return nil, err
+	}
+	return append(append([]models.ScriptTemplate{}, builtins...), saved...), nil
+}
+
+// Get 获取模板
+func Get(id string) (*models.ScriptTemplate, error) {
+	if tpl := builtin(id); tpl != nil {
+		return tpl, nil
+	}
+
+	var tpl models.ScriptTemplate
+	if err := database.GetDB().First(&tpl, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// IsBuiltIn 判断是否为内置模板
+func IsBuiltIn(id string) bool {
+	return builtin(id) != nil
+}
+
+// Validate 校验模板内容和参数定义
+func Validate(content string, defs models.ScriptParameters) error {
+	if err := params.ValidateDefinitions(defs); err != nil {
+		return err
+	}
+	if _, err := parse(content); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Render 按模板声明的参数校验输入值并渲染脚本内容
+func Render(tpl *models.ScriptTemplate, input map[string]interface{}) (string, error) {
+	values, err := params.Resolve(tpl.Parameters, input)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := parse(tpl.Content)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, values); err != nil {
+		return "", fmt.Errorf("failed to render template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// parse 解析模板内容，引用未声明的参数时渲染失败
+func parse(content string) (*template.Template, error) {
+	t, err := template.New("script").Funcs(funcs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return t, nil
+}
+
+// builtin 查找内置模板
+func builtin(id string) *models.ScriptTemplate {
+	for i := range builtins {
+		if builtins[i].ID == id {
+			tpl := builtins[i]
+			return &tpl
+		}
+	}
+	return nil
+}
+
+// quote 将参数值转换为 shell 单引号字符串
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
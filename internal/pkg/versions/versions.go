@@ -0,0 +1,113 @@
+package versions
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/file"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 版本不存在
+var ErrNotFound = errors.New("version not found")
+
+// Record 保存脚本的新版本，内容和执行器与最新版本相同时不生成新版本
+func Record(scriptID, content, executor, author, message string) (*models.ScriptVersion, error) {
+	var version models.ScriptVersion
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var latest models.ScriptVersion
+		err := tx.Where("script_id = ?", scriptID).Order("version DESC").First(&latest).Error
+		switch {
+		case err == nil:
+			if latest.Content == content && latest.Executor == executor {
+				version = latest
+				return nil
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		version = models.ScriptVersion{
+			ScriptID: scriptID,
+			Version:  latest.Version + 1,
+			Content:  content,
+			Executor: executor,
+			Author:   author,
+			Message:  message,
+		}
+		return tx.Create(&version).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record script version: %v", err)
+	}
+	return &version, nil
+}
+
+// Get 获取脚本的指定版本
+func Get(scriptID string, version int) (*models.ScriptVersion, error) {
+	var v models.ScriptVersion
+	if err := database.GetDB().Where("script_id = ? AND version = ?", scriptID, version).First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Current 获取脚本当前的版本号，没有版本记录时返回 0
+func Current(scriptID string) int {
+	var version int
+	database.GetDB().Model(&models.ScriptVersion{}).
+		Where("script_id = ?", scriptID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version)
+	return version
+}
+
+// Diff 生成两个版本之间的统一格式差异
+func Diff(from, to *models.ScriptVersion) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from.Content),
+		B:        difflib.SplitLines(to.Content),
+		FromFile: fmt.Sprintf("v%d", from.Version),
+		ToFile:   fmt.Sprintf("v%d", to.Version),
+		FromDate: from.CreatedAt.Format("2006-01-02 15:04:05"),
+		ToDate:   to.CreatedAt.Format("2006-01-02 15:04:05"),
+		Context:  3,
+	})
+}
+
+// Delete 删除脚本的全部版本
+func Delete(scriptID string) error {
+	return database.GetDB().Where("script_id = ?", scriptID).Delete(&models.ScriptVersion{}).Error
+}
+
+// Backfill 为启用版本管理之前创建的脚本生成初始版本
+func Backfill() error {
+	db := database.GetDB()
+
+	var scripts []models.Script
+	if err := db.Where("id NOT IN (?)", db.Model(&models.ScriptVersion{}).Select("script_id")).Find(&scripts).Error; err != nil {
+		return fmt.Errorf("failed to load unversioned scripts: %v", err)
+	}
+
+	for _, script := range scripts {
+		content, err := file.ReadScriptContent(script.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := Record(script.ID, content, script.Executor, "system", "Initial version"); err != nil {
+			return err
+		}
+	}
+	if len(scripts) > 0 {
+		log.Printf("📚 Created initial versions for %d scripts", len(scripts))
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package watcher
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/runner"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// defaultDebounce 默认防抖时间
+	defaultDebounce = time.Second
+	// maxChangedFilesEnv 通过环境变量传递的变更文件列表长度上限
+	maxChangedFilesEnv = 64 * 1024
+)
+
+// watch 单个脚本的文件监听
+type watch struct {
+	scriptID string
+	config   models.FileWatch
+	fs       *fsnotify.Watcher
+
+	mutex   sync.Mutex
+	pending map[string]struct{} // 防抖期间累积的变更文件
+	timer   *time.Timer
+
+	runMutex sync.Mutex // 同一脚本的监听触发依次执行
+}
+
+var (
+	// watches 当前生效的监听，scriptID -> watch
+	watches      = make(map[string]*watch)
+	watchesMutex sync.Mutex
+)
+
+// Start 为所有配置了监听目录的启用脚本开始监听
+func Start() error {
+	var scripts []models.Script
+	if err := database.GetDB().Where("enabled = ? AND watch_path <> ''", true).Find(&scripts).Error; err != nil {
+		return fmt.Errorf("failed to load watched scripts: %v", err)
+	}
+
+	for _, script := range scripts {
+		if err := add(script); err != nil {
+			log.Printf("Failed to watch %s for script %s: %v", script.Watch.Path, script.ID, err)
+		}
+	}
+	log.Printf("👀 File watcher started with %d watched scripts", len(scripts))
+	return nil
+}
+
+// Validate 校验监听配置
+func Validate(config models.FileWatch) error {
+	if config.Path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(config.Path) {
+		return fmt.Errorf("path must be absolute")
+	}
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return fmt.Errorf("path is not accessible: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("path must be a directory")
+	}
+	if _, err := filepath.Match(config.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q", config.Pattern)
+	}
+	if config.Debounce < 0 {
+		return fmt.Errorf("debounce must not be negative")
+	}
+	return nil
+}
+
+// Reload 重新加载脚本的监听配置，脚本修改、启停后调用
+func Reload(scriptID string) {
+	Remove(scriptID)
+
+	var script models.Script
+	if err := database.GetDB().First(&script, "id = ?", scriptID).Error; err != nil {
+		return
+	}
+	if !script.Enabled || script.Watch.Path == "" {
+		return
+	}
+	if err := add(script); err != nil {
+		log.Printf("Failed to watch %s for script %s: %v", script.Watch.Path, scriptID, err)
+	}
+}
+
+// Remove 停止脚本的文件监听
+func Remove(scriptID string) {
+	watchesMutex.Lock()
+	w, ok := watches[scriptID]
+	delete(watches, scriptID)
+	watchesMutex.Unlock()
+
+	if ok {
+		w.close()
+	}
+}
+
+// add 为脚本创建监听
+func add(script models.Script) error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	w := &watch{
+		scriptID: script.ID,
+		config:   script.Watch,
+		fs:       fsWatcher,
+		pending:  make(map[string]struct{}),
+	}
+	if err := w.addDir(script.Watch.Path); err != nil {
+		fsWatcher.Close()
+		return err
+	}
+
+	watchesMutex.Lock()
+	watches[script.ID] = w
+	watchesMutex.Unlock()
+
+	go w.loop()
+	return nil
+}
+
+// addDir 监听目录，递归模式下同时监听全部子目录
+func (w *watch) addDir(dir string) error {
+	if !w.config.Recursive {
+		return w.fs.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.fs.Add(path)
+		}
+		return nil
+	})
+}
+
+// loop 处理文件系统事件，监听关闭后退出
+func (w *watch) loop() {
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("File watcher error for script %s: %v", w.scriptID, err)
+		}
+	}
+}
+
+// handle 处理单个事件，只关心新建和修改
+func (w *watch) handle(event fsnotify.Event) {
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	info, err := os.Stat(event.Name)
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		// 递归模式下监听新建的子目录
+		if w.config.Recursive && event.Has(fsnotify.Create) {
+			if err := w.addDir(event.Name); err != nil {
+				log.Printf("Failed to watch %s for script %s: %v", event.Name, w.scriptID, err)
+			}
+		}
+		return
+	}
+	if !w.matches(event.Name) {
+		return
+	}
+
+	debounce := time.Duration(w.config.Debounce) * time.Millisecond
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending[event.Name] = struct{}{}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(debounce, w.fire)
+}
+
+// matches 判断文件是否匹配 glob，模式含 / 时匹配相对于监听目录的路径，否则匹配文件名
+func (w *watch) matches(path string) bool {
+	if w.config.Pattern == "" {
+		return true
+	}
+	name := filepath.Base(path)
+	if strings.Contains(w.config.Pattern, "/") {
+		rel, err := filepath.Rel(w.config.Path, path)
+		if err != nil {
+			return false
+		}
+		name = filepath.ToSlash(rel)
+	}
+	ok, _ := filepath.Match(w.config.Pattern, name)
+	return ok
+}
+
+// fire 防抖结束后执行脚本，变更的文件通过环境变量传入
+func (w *watch) fire() {
+	w.mutex.Lock()
+	paths := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		paths = append(paths, path)
+	}
+	w.pending = make(map[string]struct{})
+	w.timer = nil
+	w.mutex.Unlock()
+
+	if len(paths) == 0 {
+		return
+	}
+	sort.Strings(paths)
+
+	w.runMutex.Lock()
+	defer w.runMutex.Unlock()
+
+	changed := strings.Join(paths, "\n")
+	if len(changed) > maxChangedFilesEnv {
+		// 按整行截断，完整数量见 HOOK_CHANGED_FILE_COUNT
+		changed = changed[:maxChangedFilesEnv]
+		if i := strings.LastIndex(changed, "\n"); i > 0 {
+			changed = changed[:i]
+		}
+	}
+	result, err := runner.Run(w.scriptID, runner.Request{
+		Trigger: models.TriggerWatch,
+		Env: []string{
+			"HOOK_WATCH_DIR=" + w.config.Path,
+			"HOOK_CHANGED_FILES=" + changed,
+			"HOOK_CHANGED_FILE_COUNT=" + strconv.Itoa(len(paths)),
+		},
+	})
+	if err != nil {
+		log.Printf("Watch-triggered run of script %s failed: %v", w.scriptID, err)
+		return
+	}
+	if !result.Success {
+		log.Printf("Watch-triggered run of script %s exited with code %d", w.scriptID, result.ExitCode)
+	}
+}
+
+// close 停止监听并丢弃尚未触发的变更
+func (w *watch) close() {
+	w.mutex.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mutex.Unlock()
+	w.fs.Close()
+}
@@ -0,0 +1,224 @@
+package workflow
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/executor"
+	"hook-panel/internal/pkg/runner"
+)
+
+// stepResult 步骤执行结果
+type stepResult struct {
+	stepID string
+	status string
+}
+
+// execute 按依赖关系执行工作流的全部步骤
+func execute(wf models.Workflow, run models.WorkflowRun, stepRuns map[string]string, payload string) {
+	// 没有共享工作目录时步骤之间无法传递文件，整个运行直接失败
+	workspace, err := prepareWorkspace(run.ID)
+	if err != nil {
+		log.Printf("Workflow %s run %s: %v", wf.ID, run.ID, err)
+		finishedAt := time.Now()
+		for _, stepRunID := range stepRuns {
+			updateStep(stepRunID, map[string]interface{}{
+				"status":      models.StepStatusFailed,
+				"error":       err.Error(),
+				"finished_at": finishedAt,
+			})
+		}
+		finish(run, models.RunStatusFailed)
+		return
+	}
+	defer os.RemoveAll(workspace)
+
+	deps := dependencies(wf.Mode, wf.Steps)
+	ancestors := ancestorsOf(wf.Steps, deps)
+	status := make(map[string]string, len(wf.Steps))
+	done := make(chan stepResult)
+	running := 0
+
+	// schedule 启动依赖已全部结束的步骤，不满足条件的直接跳过
+	schedule := func() {
+		for changed := true; changed; {
+			changed = false
+			for _, step := range wf.Steps {
+				if status[step.ID] != "" || !finished(deps[step.ID], status) {
+					continue
+				}
+				changed = true
+
+				if !conditionMet(step.Condition, ancestors[step.ID], status) {
+					status[step.ID] = models.StepStatusSkipped
+					updateStep(stepRuns[step.ID], map[string]interface{}{"status": models.StepStatusSkipped})
+					continue
+				}
+
+				status[step.ID] = models.StepStatusRunning
+				running++
+				go func(step models.WorkflowStep) {
+					done <- stepResult{stepID: step.ID, status: runStep(wf, run.ID, stepRuns[step.ID], step, workspace, payload)}
+				}(step)
+			}
+		}
+	}
+
+	schedule()
+	for running > 0 {
+		result := <-done
+		running--
+		status[result.stepID] = result.status
+		schedule()
+	}
+
+	// 任一步骤失败则整个工作流失败
+	runStatus := models.RunStatusSuccess
+	for _, s := range status {
+		if s == models.StepStatusFailed {
+			runStatus = models.RunStatusFailed
+			break
+		}
+	}
+
+	finish(run, runStatus)
+}
+
+// finish 记录工作流运行的最终状态
+func finish(run models.WorkflowRun, status string) {
+	finishedAt := time.Now()
+	database.GetDB().Model(&models.WorkflowRun{}).
+		Where("id = ?", run.ID).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"duration":    finishedAt.Sub(run.StartedAt).Milliseconds(),
+			"finished_at": finishedAt,
+		})
+}
+
+// runStep 执行单个步骤并记录结果，返回步骤状态
+func runStep(wf models.Workflow, runID, stepRunID string, step models.WorkflowStep, workspace, payload string) string {
+	startedAt := time.Now()
+	updateStep(stepRunID, map[string]interface{}{
+		"status":     models.StepStatusRunning,
+		"started_at": startedAt,
+	})
+
+	req := runner.Request{
+		Trigger: models.TriggerWorkflow,
+		Params:  step.Params,
+		Stdin:   payload,
+		Env: []string{
+			"HOOK_WORKFLOW_ID=" + wf.ID,
+			"HOOK_WORKFLOW_RUN_ID=" + runID,
+			"HOOK_WORKFLOW_STEP=" + step.ID,
+			"HOOK_WORKSPACE=" + workspace,
+		},
+		Dir:     workspace,
+		NoChain: true,
+	}
+
+	// 内联步骤的日志和运行记录归属于工作流
+	var err error
+	var result *executor.ExecutionResult
+	if step.ScriptID != "" {
+		result, err = runner.Run(step.ScriptID, req)
+	} else {
+		result, err = runner.RunInline(wf.ID, step.Content, step.Executor, req)
+	}
+
+	updates := map[string]interface{}{
+		"status":      models.StepStatusSuccess,
+		"finished_at": time.Now(),
+	}
+	switch {
+	case err != nil:
+		updates["status"] = models.StepStatusFailed
+		updates["exit_code"] = -1
+		updates["error"] = err.Error()
+	default:
+		updates["run_id"] = result.RunID
+		updates["exit_code"] = result.ExitCode
+		updates["output"] = truncate(result.Output)
+		updates["error"] = truncate(result.Error)
+		if !result.Success {
+			updates["status"] = models.StepStatusFailed
+		}
+	}
+	updateStep(stepRunID, updates)
+	return updates["status"].(string)
+}
+
+// ancestorsOf 计算每个步骤的全部上游步骤
+func ancestorsOf(steps models.WorkflowSteps, deps map[string][]string) map[string][]string {
+	result := make(map[string][]string, len(steps))
+
+	var collect func(id string, seen map[string]bool)
+	collect = func(id string, seen map[string]bool) {
+		for _, dep := range deps[id] {
+			if !seen[dep] {
+				seen[dep] = true
+				collect(dep, seen)
+			}
+		}
+	}
+
+	for _, step := range steps {
+		seen := make(map[string]bool)
+		collect(step.ID, seen)
+		for id := range seen {
+			result[step.ID] = append(result[step.ID], id)
+		}
+	}
+	return result
+}
+
+// finished 判断依赖的步骤是否都已结束
+func finished(deps []string, status map[string]string) bool {
+	for _, dep := range deps {
+		switch status[dep] {
+		case models.StepStatusSuccess, models.StepStatusFailed, models.StepStatusSkipped:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// conditionMet 根据上游步骤是否有失败判断步骤是否需要执行
+func conditionMet(condition string, ancestors []string, status map[string]string) bool {
+	failed := false
+	for _, id := range ancestors {
+		if status[id] == models.StepStatusFailed {
+			failed = true
+			break
+		}
+	}
+
+	switch condition {
+	case models.StepConditionAlways:
+		return true
+	case models.StepConditionFailure:
+		return failed
+	default:
+		return !failed
+	}
+}
+
+// updateStep 更新步骤运行记录
+func updateStep(stepRunID string, updates map[string]interface{}) {
+	if err := database.GetDB().Model(&models.WorkflowStepRun{}).Where("id = ?", stepRunID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update workflow step run %s: %v", stepRunID, err)
+	}
+}
+
+// truncate 截断过长的输出
+func truncate(s string) string {
+	if len(s) > maxStepOutput {
+		return s[:maxStepOutput] + "\n... (truncated)"
+	}
+	return s
+}
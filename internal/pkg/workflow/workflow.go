@@ -0,0 +1,231 @@
+package workflow
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/executor"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// WorkspacesDir 工作流运行期间各步骤共享的工作目录
+	WorkspacesDir = "./data/workspaces"
+	// maxStepOutput 步骤记录中保存的输出长度上限
+	maxStepOutput = 64 * 1024
+)
+
+// stepIDPattern 步骤标识格式
+var stepIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Validate 校验工作流的步骤定义
+func Validate(mode string, steps models.WorkflowSteps) error {
+	if mode != "" && mode != models.WorkflowModeSequential && mode != models.WorkflowModeDAG {
+		return fmt.Errorf("mode must be %s or %s", models.WorkflowModeSequential, models.WorkflowModeDAG)
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+
+	db := database.GetDB()
+	seen := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		if !stepIDPattern.MatchString(step.ID) {
+			return fmt.Errorf("step id %q must be 1-64 letters, digits, '_' or '-'", step.ID)
+		}
+		if seen[step.ID] {
+			return fmt.Errorf("duplicate step id %q", step.ID)
+		}
+		seen[step.ID] = true
+
+		switch {
+		case step.ScriptID != "" && step.Content != "":
+			return fmt.Errorf("step %s: script_id and content are mutually exclusive", step.ID)
+		case step.ScriptID != "":
+			var count int64
+			if err := db.Model(&models.Script{}).Where("id = ?", step.ScriptID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("step %s: script %s not found", step.ID, step.ScriptID)
+			}
+		case step.Content != "":
+			if !executor.Supported(step.Executor) {
+				return fmt.Errorf("step %s: unsupported executor %q", step.ID, step.Executor)
+			}
+		default:
+			return fmt.Errorf("step %s: script_id or content is required", step.ID)
+		}
+
+		switch step.Condition {
+		case "", models.StepConditionSuccess, models.StepConditionFailure, models.StepConditionAlways:
+		default:
+			return fmt.Errorf("step %s: condition must be %s, %s or %s", step.ID,
+				models.StepConditionSuccess, models.StepConditionFailure, models.StepConditionAlways)
+		}
+		if mode != models.WorkflowModeDAG && len(step.DependsOn) > 0 {
+			return fmt.Errorf("step %s: depends_on is only allowed in %s mode", step.ID, models.WorkflowModeDAG)
+		}
+	}
+
+	deps := dependencies(mode, steps)
+	for _, step := range steps {
+		for _, dep := range deps[step.ID] {
+			if !seen[dep] {
+				return fmt.Errorf("step %s depends on unknown step %q", step.ID, dep)
+			}
+			if dep == step.ID {
+				return fmt.Errorf("step %s cannot depend on itself", step.ID)
+			}
+		}
+		if step.Condition == models.StepConditionFailure && len(deps[step.ID]) == 0 {
+			return fmt.Errorf("step %s: condition %s requires dependencies", step.ID, models.StepConditionFailure)
+		}
+	}
+	if hasCycle(steps, deps) {
+		return fmt.Errorf("steps contain a dependency cycle")
+	}
+	return nil
+}
+
+// Start 创建工作流运行记录并在后台执行，payload 作为各步骤的标准输入
+func Start(wf models.Workflow, trigger, payload string) (*models.WorkflowRun, error) {
+	db := database.GetDB()
+
+	run := models.WorkflowRun{
+		WorkflowID: wf.ID,
+		Trigger:    trigger,
+		Status:     models.RunStatusRunning,
+		StartedAt:  time.Now(),
+	}
+	for i, step := range wf.Steps {
+		run.StepRuns = append(run.StepRuns, models.WorkflowStepRun{
+			StepID:   step.ID,
+			Name:     step.Name,
+			Position: i,
+			Status:   models.StepStatusPending,
+		})
+	}
+	if err := db.Create(&run).Error; err != nil {
+		return nil, fmt.Errorf("failed to create workflow run: %v", err)
+	}
+
+	// 更新调用统计
+	db.Model(&models.Workflow{}).
+		Where("id = ?", wf.ID).
+		Updates(map[string]interface{}{
+			"call_count":   gorm.Expr("call_count + 1"),
+			"last_call_at": time.Now(),
+		})
+
+	stepRuns := make(map[string]string, len(run.StepRuns))
+	for _, stepRun := range run.StepRuns {
+		stepRuns[stepRun.StepID] = stepRun.ID
+	}
+	go execute(wf, run, stepRuns, payload)
+
+	return &run, nil
+}
+
+// Recover 将服务重启前未结束的工作流运行标记为失败
+func Recover() {
+	db := database.GetDB()
+	now := time.Now()
+
+	var runIDs []string
+	db.Model(&models.WorkflowRun{}).Where("status = ?", models.RunStatusRunning).Pluck("id", &runIDs)
+	if len(runIDs) == 0 {
+		return
+	}
+
+	db.Model(&models.WorkflowStepRun{}).
+		Where("workflow_run_id IN ? AND status IN ?", runIDs, []string{models.StepStatusPending, models.StepStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.StepStatusFailed,
+			"error":       "interrupted by service restart",
+			"finished_at": now,
+		})
+	db.Model(&models.WorkflowRun{}).
+		Where("id IN ?", runIDs).
+		Updates(map[string]interface{}{
+			"status":      models.RunStatusFailed,
+			"finished_at": now,
+		})
+	log.Printf("Marked %d interrupted workflow runs as failed", len(runIDs))
+}
+
+// Delete 删除工作流的全部运行记录和 webhook 调用记录
+func Delete(workflowID string) {
+	db := database.GetDB()
+	db.Where("workflow_run_id IN (?)", db.Model(&models.WorkflowRun{}).Select("id").Where("workflow_id = ?", workflowID)).
+		Delete(&models.WorkflowStepRun{})
+	db.Where("workflow_id = ?", workflowID).Delete(&models.WorkflowRun{})
+	db.Where("workflow_id = ?", workflowID).Delete(&models.WorkflowWebhookLog{})
+}
+
+// dependencies 计算每个步骤的直接依赖，顺序模式下依赖上一步
+func dependencies(mode string, steps models.WorkflowSteps) map[string][]string {
+	deps := make(map[string][]string, len(steps))
+	for i, step := range steps {
+		if mode == models.WorkflowModeDAG {
+			deps[step.ID] = step.DependsOn
+		} else if i > 0 {
+			deps[step.ID] = []string{steps[i-1].ID}
+		}
+	}
+	return deps
+}
+
+// hasCycle 判断依赖关系中是否存在环
+func hasCycle(steps models.WorkflowSteps, deps map[string][]string) bool {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(steps))
+
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		switch state[id] {
+		case visiting:
+			return true
+		case visited:
+			return false
+		}
+		state[id] = visiting
+		for _, dep := range deps[id] {
+			if visit(dep) {
+				return true
+			}
+		}
+		state[id] = visited
+		return false
+	}
+
+	for _, step := range steps {
+		if visit(step.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareWorkspace 创建工作流运行的共享工作目录
+func prepareWorkspace(runID string) (string, error) {
+	dir, err := filepath.Abs(filepath.Join(WorkspacesDir, runID))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create workspace: %v", err)
+	}
+	return dir, nil
+}
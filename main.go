@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"flag"
 	"fmt"
@@ -8,17 +9,33 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"hook-panel/internal/handlers"
 	"hook-panel/internal/middleware"
+	"hook-panel/internal/models"
+	"hook-panel/internal/pkg/artifact"
 	"hook-panel/internal/pkg/auth"
 	"hook-panel/internal/pkg/database"
+	"hook-panel/internal/pkg/gitstore"
 	"hook-panel/internal/pkg/i18n"
+	"hook-panel/internal/pkg/provision"
+	"hook-panel/internal/pkg/runner"
+	"hook-panel/internal/pkg/scheduler"
+	"hook-panel/internal/pkg/sysconfig"
+	"hook-panel/internal/pkg/versions"
+	"hook-panel/internal/pkg/watcher"
+	"hook-panel/internal/pkg/workflow"
 
 	"github.com/gin-gonic/gin"
 )
 
+// shutdownTimeout 退出时等待进行中请求完成的最长时间
+const shutdownTimeout = 10 * time.Second
+
 //go:embed web/dist/*
 var staticFiles embed.FS
 
@@ -196,16 +213,30 @@ func isHexString(s string) bool {
 }
 
 func main() {
+	// 执行导入导出等子命令
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	// 解析命令行参数
 	var port string
 	flag.StringVar(&port, "port", "", "Server port (default: 8080)")
 	flag.StringVar(&port, "p", "", "Server port (short)")
+	var configFile string
+	flag.StringVar(&configFile, "config", "", "Declarative scripts configuration file (YAML), reloaded on SIGHUP")
+	var secretKeyFile string
+	flag.StringVar(&secretKeyFile, "secret-key-file", "", "Read the access key from this file (e.g. a mounted secret) instead of ./data/secret.key")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Hook Panel - Lightweight Webhook Script Management Platform\n\n")
 		fmt.Fprintf(os.Stderr, "Usage:\n")
-		fmt.Fprintf(os.Stderr, "  %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s export [options]             # Export scripts as a bundle\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s import [options] <bundle>    # Import scripts from a bundle\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment:\n")
+		fmt.Fprintf(os.Stderr, "  %s         Access key (used when --secret-key-file is not set)\n", auth.SecretKeyEnv)
+		fmt.Fprintf(os.Stderr, "  %s    Path of a file containing the access key\n", auth.SecretKeyFileEnv)
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s --port 3000    # Start service on port 3000\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -p 8888        # Start service on port 8888\n", os.Args[0])
@@ -216,7 +247,7 @@ func main() {
 
 	// Initialize secret key
 	log.Println("🔑 Initializing secret key...")
-	if err := auth.InitSecretKey(); err != nil {
+	if err := auth.InitSecretKey(secretKeyFile); err != nil {
 		log.Fatal("Failed to initialize secret key:", err)
 	}
 
@@ -226,6 +257,46 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	// Encrypt sensitive configs still stored as plaintext
+	if err := sysconfig.EncryptExisting(); err != nil {
+		log.Fatal("Failed to encrypt configs:", err)
+	}
+	if err := auth.EncryptTOTPSecrets(); err != nil {
+		log.Fatal("Failed to encrypt two-factor secrets:", err)
+	}
+
+	// Initialize git storage
+	if err := gitstore.Init(); err != nil {
+		log.Fatal("Failed to initialize git storage:", err)
+	}
+
+	// Create initial versions for scripts saved before versioning
+	if err := versions.Backfill(); err != nil {
+		log.Fatal("Failed to initialize script versions:", err)
+	}
+
+	// Start scheduler
+	log.Println("⏰ Starting scheduler...")
+	if err := scheduler.Start(); err != nil {
+		log.Fatal("Failed to start scheduler:", err)
+	}
+
+	// Start file watchers
+	if err := watcher.Start(); err != nil {
+		log.Fatal("Failed to start file watcher:", err)
+	}
+
+	// Apply declarative configuration file
+	if err := provision.Init(configFile); err != nil {
+		log.Fatal("Failed to apply configuration file:", err)
+	}
+
+	// Recover interrupted workflow runs
+	workflow.Recover()
+
+	// Start artifact retention cleanup
+	artifact.StartCleanup()
+
 	// Initialize i18n system
 	log.Println("🌐 Initializing i18n system...")
 	i18n.Init()
@@ -237,6 +308,16 @@ func main() {
 	// 创建路由器
 	r := gin.New()
 
+	// 只采信配置的代理转发的客户端 IP，未配置时忽略 X-Forwarded-For 等请求头，
+	// 防止伪造 IP 绕过认证锁定或写入审计日志（重启后生效）
+	var trustedProxies []string
+	if proxies := strings.TrimSpace(sysconfig.GetString("auth.trusted_proxies", "")); proxies != "" && proxies != "none" {
+		trustedProxies = strings.Split(strings.ReplaceAll(proxies, " ", ""), ",")
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid auth.trusted_proxies:", err)
+	}
+
 	// 添加基础中间件
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
@@ -254,43 +335,178 @@ func main() {
 		webhook.POST("/:id", handlers.WebhookHandler)
 	}
 
+	// 工作流 Webhook 路由
+	workflowWebhook := r.Group("/hw")
+	workflowWebhook.Use(middleware.I18nMiddleware())
+	{
+		workflowWebhook.POST("/:id", handlers.WorkflowWebhookHandler)
+	}
+
+	// 登录路由（无需认证）
+	authGroup := r.Group("/api/auth")
+	authGroup.Use(middleware.I18nMiddleware())
+	authGroup.Use(middleware.Audit())
+	{
+		authGroup.POST("/login", handlers.Login)
+		authGroup.GET("/oidc", handlers.GetOIDCStatus)
+		authGroup.GET("/oidc/login", handlers.OIDCLogin)
+		authGroup.GET("/oidc/callback", handlers.OIDCCallback)
+	}
+
 	// 需要认证的路由组
 	api := r.Group("/api")
 	api.Use(middleware.I18nMiddleware())
 	api.Use(middleware.AuthMiddleware())
+	api.Use(middleware.Audit())
 	{
+		// 按全局角色限制的操作，脚本路由按脚本授权单独校验
+		admin := middleware.RequireRole(models.RoleAdmin)
+		editor := middleware.RequireRole(models.RoleEditor)
+		operator := middleware.RequireRole(models.RoleOperator)
+		// 限定脚本的 API 令牌只能访问按脚本校验或过滤的路由
+		unrestricted := middleware.Unrestricted()
+
 		// 仪表板统计
-		api.GET("/dashboard/stats", handlers.GetDashboardStats)
+		api.GET("/dashboard/stats", unrestricted, handlers.GetDashboardStats)
 
 		// 脚本管理路由
 		scripts := api.Group("/scripts")
+		scripts.Use(middleware.ScriptAccess("/api/scripts"))
+		{
+			scripts.GET("", handlers.GetScripts)                                          // 获取脚本列表
+			scripts.POST("", handlers.CreateScript)                                       // 创建脚本
+			scripts.POST("/from-template", handlers.CreateScriptFromTemplate)             // 从模板创建脚本
+			scripts.GET("/:id", handlers.GetScript)                                       // 获取单个脚本
+			scripts.PUT("/:id", handlers.UpdateScript)                                    // 更新脚本
+			scripts.DELETE("/:id", handlers.DeleteScript)                                 // 删除脚本
+			scripts.POST("/:id/toggle", handlers.ToggleScript)                            // 切换脚本状态
+			scripts.POST("/:id/execute", handlers.ExecuteScript)                          // 执行脚本
+			scripts.GET("/:id/logs", handlers.GetScriptLogs)                              // 获取脚本日志
+			scripts.DELETE("/:id/logs", handlers.ClearScriptLogs)                         // 清空脚本日志
+			scripts.GET("/:id/webhook", handlers.GetWebhookURL)                           // 获取 webhook URL
+			scripts.GET("/:id/webhook-logs", handlers.GetWebhookLogs)                     // 获取 webhook 调用记录
+			scripts.GET("/:id/webhook-stats", handlers.GetWebhookLogStats)                // 获取 webhook 调用统计
+			scripts.DELETE("/:id/webhook-logs", handlers.ClearWebhookLogs)                // 清空 webhook 调用记录
+			scripts.GET("/:id/runs", handlers.GetScriptRuns)                              // 获取运行记录
+			scripts.POST("/:id/schedule", handlers.CreateScheduledRun)                    // 创建延时/定点运行
+			scripts.GET("/:id/schedule", handlers.GetScheduledRuns)                       // 获取脚本的计划运行
+			scripts.GET("/:id/versions", handlers.GetScriptVersions)                      // 获取历史版本
+			scripts.GET("/:id/versions/diff", handlers.DiffScriptVersions)                // 对比两个版本
+			scripts.GET("/:id/versions/:version", handlers.GetScriptVersion)              // 获取指定版本
+			scripts.POST("/:id/versions/:version/restore", handlers.RestoreScriptVersion) // 恢复到指定版本
+		}
+
+		// 脚本模板路由
+		templates := api.Group("/templates")
+		templates.Use(unrestricted)
+		{
+			templates.GET("", handlers.GetTemplates)                  // 获取模板列表
+			templates.POST("", editor, handlers.CreateTemplate)       // 保存模板
+			templates.GET("/:id", handlers.GetTemplate)               // 获取单个模板
+			templates.PUT("/:id", editor, handlers.UpdateTemplate)    // 更新模板
+			templates.DELETE("/:id", editor, handlers.DeleteTemplate) // 删除模板
+		}
+
+		// 计划运行路由
+		scheduledRuns := api.Group("/scheduled-runs")
 		{
-			scripts.GET("", handlers.GetScripts)                           // 获取脚本列表
-			scripts.POST("", handlers.CreateScript)                        // 创建脚本
-			scripts.GET("/:id", handlers.GetScript)                        // 获取单个脚本
-			scripts.PUT("/:id", handlers.UpdateScript)                     // 更新脚本
-			scripts.DELETE("/:id", handlers.DeleteScript)                  // 删除脚本
-			scripts.POST("/:id/toggle", handlers.ToggleScript)             // 切换脚本状态
-			scripts.POST("/:id/execute", handlers.ExecuteScript)           // 执行脚本
-			scripts.GET("/:id/logs", handlers.GetScriptLogs)               // 获取脚本日志
-			scripts.DELETE("/:id/logs", handlers.ClearScriptLogs)          // 清空脚本日志
-			scripts.GET("/:id/webhook", handlers.GetWebhookURL)            // 获取 webhook URL
-			scripts.GET("/:id/webhook-logs", handlers.GetWebhookLogs)      // 获取 webhook 调用记录
-			scripts.GET("/:id/webhook-stats", handlers.GetWebhookLogStats) // 获取 webhook 调用统计
-			scripts.DELETE("/:id/webhook-logs", handlers.ClearWebhookLogs) // 清空 webhook 调用记录
+			scheduledRuns.GET("", handlers.GetScheduledRuns)                            // 获取全部计划运行
+			scheduledRuns.DELETE("/:scheduleId", operator, handlers.CancelScheduledRun) // 取消计划运行
 		}
 
+		// 运行记录路由
+		runs := api.Group("/runs")
+		{
+			runs.GET("/:runId", handlers.GetRun)                                 // 获取运行详情（包含每次尝试）
+			runs.GET("/:runId/artifacts", handlers.GetRunArtifacts)              // 获取运行产物列表
+			runs.GET("/:runId/artifacts/download", handlers.DownloadRunArtifact) // 下载单个产物
+			runs.GET("/:runId/artifacts/zip", handlers.DownloadRunArtifactsZip)  // 打包下载全部产物
+		}
+
+		// 工作流路由
+		workflows := api.Group("/workflows")
+		workflows.Use(unrestricted)
+		{
+			workflows.GET("", handlers.GetWorkflows)                                // 获取工作流列表
+			workflows.POST("", editor, handlers.CreateWorkflow)                     // 创建工作流
+			workflows.GET("/:id", handlers.GetWorkflow)                             // 获取单个工作流
+			workflows.PUT("/:id", editor, handlers.UpdateWorkflow)                  // 更新工作流
+			workflows.DELETE("/:id", editor, handlers.DeleteWorkflow)               // 删除工作流
+			workflows.POST("/:id/execute", operator, handlers.ExecuteWorkflow)      // 运行工作流
+			workflows.GET("/:id/webhook", operator, handlers.GetWorkflowWebhookURL) // 获取 webhook URL
+			workflows.GET("/:id/runs", handlers.GetWorkflowRuns)                    // 获取运行记录
+			workflows.GET("/:id/webhook-logs", handlers.GetWorkflowWebhookLogs)     // 获取 webhook 调用记录
+		}
+		api.GET("/workflow-runs/:runId", unrestricted, handlers.GetWorkflowRun) // 获取工作流运行详情（包含每个步骤）
+
 		// 全局 webhook 日志路由
 		webhookLogs := api.Group("/webhook-logs")
 		{
 			webhookLogs.GET("", handlers.GetWebhookLogs) // 获取所有 webhook 调用记录
 		}
 
+		// 导入导出路由
+		api.GET("/export", handlers.ExportBundle)         // 导出脚本包
+		api.POST("/import", admin, handlers.ImportBundle) // 导入脚本包
+
+		// 脚本存储路由
+		api.POST("/storage/pull", admin, handlers.PullStorage) // 从远程 Git 仓库拉取脚本
+
+		// 当前账号路由
+		api.POST("/auth/logout", handlers.Logout)                               // 退出登录
+		api.GET("/auth/me", handlers.GetCurrentUser)                            // 获取当前身份
+		api.PUT("/auth/password", handlers.ChangePassword)                      // 修改密码
+		api.POST("/auth/totp/setup", handlers.SetupTOTP)                        // 开始绑定两步验证
+		api.POST("/auth/totp/enable", handlers.EnableTOTP)                      // 确认绑定两步验证
+		api.POST("/auth/totp/disable", handlers.DisableTOTP)                    // 关闭两步验证
+		api.POST("/auth/totp/recovery-codes", handlers.RegenerateRecoveryCodes) // 重新生成恢复码
+		api.POST("/auth/rotate-key", admin, handlers.RotateKey)                 // 轮换访问密钥
+
+		// 用户管理路由
+		users := api.Group("/users")
+		users.Use(admin)
+		{
+			users.GET("", handlers.GetUsers)                              // 获取用户列表
+			users.POST("", handlers.CreateUser)                           // 创建用户
+			users.PUT("/:id", handlers.UpdateUser)                        // 更新用户
+			users.DELETE("/:id", handlers.DeleteUser)                     // 删除用户
+			users.GET("/:id/permissions", handlers.GetUserPermissions)    // 获取用户的脚本授权
+			users.PUT("/:id/permissions", handlers.UpdateUserPermissions) // 替换用户的脚本授权
+		}
+
+		// API 令牌路由
+		tokens := api.Group("/tokens")
+		tokens.Use(admin)
+		{
+			tokens.GET("", handlers.GetAPITokens)          // 获取 API 令牌列表
+			tokens.POST("", handlers.CreateAPIToken)       // 创建 API 令牌
+			tokens.GET("/:id", handlers.GetAPIToken)       // 获取单个 API 令牌
+			tokens.PUT("/:id", handlers.UpdateAPIToken)    // 更新 API 令牌
+			tokens.DELETE("/:id", handlers.DeleteAPIToken) // 吊销 API 令牌
+		}
+
+		// 审计日志路由
+		auditLog := api.Group("/audit")
+		auditLog.Use(admin)
+		{
+			auditLog.GET("", handlers.GetAuditEvents)           // 获取审计日志
+			auditLog.GET("/export", handlers.ExportAuditEvents) // 导出审计日志（JSON Lines）
+		}
+
+		// 认证锁定路由
+		lockouts := api.Group("/lockouts")
+		lockouts.Use(admin)
+		{
+			lockouts.GET("", handlers.GetLockouts)         // 获取认证失败记录和锁定
+			lockouts.DELETE("", handlers.ClearLockouts)    // 解除全部锁定
+			lockouts.DELETE("/:ip", handlers.ClearLockout) // 解除单个 IP 的锁定
+		}
+
 		// 系统配置路由
 		config := api.Group("/config")
 		{
-			config.GET("", handlers.GetSystemConfigs)    // 获取系统配置
-			config.PUT("", handlers.UpdateSystemConfigs) // 更新系统配置
+			config.GET("", unrestricted, handlers.GetSystemConfigs) // 获取系统配置
+			config.PUT("", admin, handlers.UpdateSystemConfigs)     // 更新系统配置
 		}
 	}
 
@@ -302,8 +518,25 @@ func main() {
 		}
 	}
 
-	log.Printf("🚀 Service started on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start service:", err)
+	server := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		log.Printf("🚀 Service started on port %s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start service:", err)
+		}
+	}()
+
+	// 收到退出信号后取消等待中的重试，等待进行中的请求和运行完成后退出
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+	log.Println("🛑 Shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := runner.Shutdown(ctx); err != nil {
+		log.Printf("Stopped waiting for scripts: %v", err)
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to shut down gracefully: %v", err)
 	}
 }
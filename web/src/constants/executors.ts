@@ -29,6 +29,29 @@ echo "Webhook request received"
 current_time=$(date '+%Y-%m-%d %H:%M:%S')
 echo "Execution time: $current_time"
 
+# Write your script logic here
+echo "Script execution completed"`,
+  },
+  {
+    value: 'auto',
+    label: '✨ Auto (Shebang)',
+    icon: '✨',
+    color: '#08979c',
+    text: 'Auto',
+    status: 'Default',
+    fileExtension: '.sh',
+    defaultTemplate: `#!/bin/bash -euo pipefail
+
+# Webhook Script Example - Auto
+# The interpreter and its flags are taken from the shebang line above,
+# scripts without a shebang fall back to bash
+
+echo "Webhook request received"
+
+# Get current time
+current_time=$(date '+%Y-%m-%d %H:%M:%S')
+echo "Execution time: $current_time"
+
 # Write your script logic here
 echo "Script execution completed"`,
   },
@@ -287,6 +310,58 @@ public class WebhookScript {
         // Write your script logic here
         System.out.println("Script execution completed");
     }
+}`,
+  },
+  {
+    value: 'rust',
+    label: '🦀 Rust',
+    icon: '🦀',
+    color: '#a0522d',
+    text: 'Rust',
+    status: 'Warning',
+    fileExtension: '.rs',
+    defaultTemplate: `// Webhook Script Example - Rust
+// Requires the Rust toolchain to be configured in system settings
+
+use std::time::{SystemTime, UNIX_EPOCH};
+
+fn main() {
+    println!("Webhook request received");
+
+    // Get current time
+    let now = SystemTime::now().duration_since(UNIX_EPOCH).unwrap();
+    println!("Execution time: {}", now.as_secs());
+
+    // Write your script logic here
+    println!("Script execution completed");
+}`,
+  },
+  {
+    value: 'c',
+    label: '🔧 C',
+    icon: '🔧',
+    color: '#5b8c00',
+    text: 'C',
+    status: 'Warning',
+    fileExtension: '.c',
+    defaultTemplate: `// Webhook Script Example - C
+// Requires the C compiler to be configured in system settings
+
+#include <stdio.h>
+#include <time.h>
+
+int main(void) {
+    printf("Webhook request received\\n");
+
+    // Get current time
+    char buffer[32];
+    time_t now = time(NULL);
+    strftime(buffer, sizeof(buffer), "%Y-%m-%d %H:%M:%S", localtime(&now));
+    printf("Execution time: %s\\n", buffer);
+
+    // Write your script logic here
+    printf("Script execution completed\\n");
+    return 0;
 }`,
   },
   {